@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+// Oracle instruction prepended to prompts (read-only advisory role)
+const oracleInstruction = `You are a read-only advisory oracle consulted for complex analysis, planning, and reviews.
+Do not perform implementation work yourself - focus strictly on researching, analyzing, and providing expert guidance.
+Review the situation thoroughly and respond with actionable advice that adheres to the query's requirements.
+
+USER QUERY:
+`
+
+var bigBrain = &shared.Tool{
+	Name:    "big-brain",
+	Summary: "Read-only advisory oracle for analysis, planning and reviews",
+	Usage: `Usage: big-brain [-s SESSION_ID] "prompt"
+   or: echo "prompt" | big-brain [-s SESSION_ID]
+   or: big-brain [-s SESSION_ID] <<EOF
+       prompt text here
+       EOF`,
+	Examples: `  big-brain "How should we refactor the auth system?"
+  echo "What's the best approach for real-time notifications?" | big-brain
+  big-brain -s ses_abc123 "continue with the implementation details"`,
+	PromptPrefix: oracleInstruction,
+	Timeout:      10 * time.Minute,
+	Isolated:     true,
+}
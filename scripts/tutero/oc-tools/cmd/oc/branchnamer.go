@@ -0,0 +1,24 @@
+package main
+
+import (
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+var branchNamer = &shared.Tool{
+	Name:    "branch-namer",
+	Summary: "Generate a kebab-case branch name from a PR title",
+	Usage: `Usage: branch-namer [-v] "PR title or description"
+   or: echo "PR title" | branch-namer`,
+	Description: `Generates a kebab-case branch/folder name from a PR title.
+Sessions are automatically deleted after use (no history pollution).`,
+	Notes: `Output: JSON with branchName field, e.g. {"branchName": "fix-auth-bug"}`,
+	// Use /tmp as workdir to isolate from real projects
+	WorkDir: "/tmp",
+	// Short timeout (branch naming is quick)
+	Timeout: 2 * time.Minute,
+	// Output is consumed by scripts: just the text, no session info
+	Quiet:       true,
+	AutoCleanup: true,
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tutero/oc-tools/shared"
+)
+
+// completeCommand is the hidden subcommand the completion scripts call back into:
+//
+//	oc __complete <command line words...> <word being completed>
+//
+// It prints one candidate per line, optionally followed by a tab and a description
+const completeCommand = "__complete"
+
+// Recent sessions offered for -s
+const completeSessionLimit = 20
+
+// Completion scripts: each hands the words before the cursor plus the current word to
+// `oc __complete` so all the logic (tools, flags, session IDs) lives in one place
+const bashCompletion = `# bash completion for oc-tools
+# Install: oc completion bash > ~/.local/share/bash-completion/completions/oc
+#      or: source <(oc completion bash)
+_oc_tools() {
+    local IFS=$'\n'
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    COMPREPLY=($(oc __complete "${COMP_WORDS[@]:0:COMP_CWORD}" "$cur" 2>/dev/null | cut -f1))
+}
+complete -o default -F _oc_tools oc %[1]s
+`
+
+const zshCompletion = `#compdef oc %[1]s
+# zsh completion for oc-tools
+# Install: oc completion zsh > "${fpath[1]}/_oc"
+#      or: source <(oc completion zsh)
+_oc_tools() {
+    local -a lines items
+    local line id desc
+    lines=("${(@f)$(oc __complete "${(@)words[1,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    for line in $lines; do
+        [[ -z $line ]] && continue
+        id=${line%%$'\t'*}
+        desc=""
+        [[ $line == *$'\t'* ]] && desc=${line#*$'\t'}
+        items+=("${id//:/\\:}:$desc")
+    done
+    if (( ${#items} )); then
+        _describe -t values 'oc' items
+    else
+        _files
+    fi
+}
+compdef _oc_tools oc %[1]s
+`
+
+const fishCompletion = `# fish completion for oc-tools
+# Install: oc completion fish > ~/.config/fish/completions/oc.fish
+function __oc_tools_complete
+    oc __complete (commandline -opc) (commandline -ct) 2>/dev/null
+end
+for cmd in oc %[1]s
+    complete -c $cmd -f -a '(__oc_tools_complete)'
+end
+`
+
+// completion prints the completion script for a shell
+func completion(args []string) int {
+	scripts := map[string]string{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	if len(args) != 1 || scripts[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: oc completion bash|zsh|fish")
+		return 2
+	}
+
+	// Manifest tools can be symlinked to oc like the built-ins
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	for _, cmd := range commands {
+		names = append(names, "oc-"+cmd.Name)
+	}
+	for _, p := range loadPlugins() {
+		if p.Tool != nil {
+			names = append(names, p.Name)
+		}
+	}
+	fmt.Printf(scripts[args[0]], strings.Join(names, " "))
+	return 0
+}
+
+// complete prints candidates for the last word given the words before it
+func complete(words []string) int {
+	if len(words) == 0 {
+		return 0
+	}
+	cur := words[len(words)-1]
+	words = words[:len(words)-1]
+	if len(words) == 0 {
+		return 0
+	}
+
+	var candidates []string
+	tool := findTool(filepath.Base(words[0]))
+	rest := words[1:]
+
+	// oc's own subcommands
+	cmd := findCommand(filepath.Base(words[0]))
+	if cmd == nil && tool == nil && len(rest) > 0 {
+		if cmd = findCommand(rest[0]); cmd != nil {
+			rest = rest[1:]
+		}
+	}
+	if cmd != nil {
+		if cmd.Complete != nil {
+			printMatches(cmd.Complete(rest, cur), cur)
+		} else if strings.HasPrefix(cur, "-") {
+			printMatches(cmd.Flags, cur)
+		}
+		return 0
+	}
+
+	if tool == nil {
+		// oc itself: the command comes first
+		if len(rest) == 0 {
+			for _, t := range tools {
+				candidates = append(candidates, t.Name+"\t"+t.Summary)
+			}
+			for _, cmd := range commands {
+				candidates = append(candidates, cmd.Name+"\t"+cmd.Summary)
+			}
+			for _, p := range loadPlugins() {
+				candidates = append(candidates, p.Name+"\t"+p.Summary)
+			}
+			candidates = append(candidates, "--parent\tCreate new sessions as children of this one", "completion\tPrint a shell completion script", "help\tShow help for a command")
+			printMatches(candidates, cur)
+			return 0
+		}
+		switch rest[0] {
+		case "completion":
+			if len(rest) == 1 {
+				printMatches([]string{"bash", "zsh", "fish"}, cur)
+			}
+			return 0
+		case "help":
+			if len(rest) == 1 {
+				for _, t := range tools {
+					candidates = append(candidates, t.Name)
+				}
+				for _, cmd := range commands {
+					candidates = append(candidates, cmd.Name)
+				}
+				for _, p := range loadPlugins() {
+					candidates = append(candidates, p.Name)
+				}
+				printMatches(candidates, cur)
+			}
+			return 0
+		case "--parent":
+			// Any session may be a parent; offer the recent ones of every tool
+			if len(rest) == 1 {
+				for _, t := range tools {
+					for _, session := range shared.RecentSessions(t.Name, completeSessionLimit) {
+						candidates = append(candidates, session.ID+"\t"+t.Name+" "+session.ModTime.Format("2006-01-02 15:04"))
+					}
+				}
+				printMatches(candidates, cur)
+				return 0
+			}
+			// Complete the command after `--parent ID`
+			return complete(append(append([]string{words[0]}, rest[2:]...), cur))
+		}
+		if tool = findTool(rest[0]); tool == nil {
+			return 0
+		}
+		rest = rest[1:]
+	}
+
+	prev := ""
+	if len(rest) > 0 {
+		prev = rest[len(rest)-1]
+	}
+
+	switch strings.TrimLeft(prev, "-") {
+	case "s", "session", "fork":
+		for _, session := range shared.RecentSessions(tool.Name, completeSessionLimit) {
+			candidates = append(candidates, session.ID+"\t"+session.ModTime.Format("2006-01-02 15:04"))
+		}
+	case "workdir":
+		candidates = completeDirs(cur)
+	case "db":
+		if tool == dbOracle {
+			candidates = []string{"resources", "learning", "teaching"}
+		}
+	case "agent":
+		candidates = completeAgents()
+	case "timeout", "model", "tool", "system", "system-file", "file":
+		// Free-form values (files fall back to the shell's own completion)
+	default:
+		if strings.HasPrefix(cur, "-") {
+			candidates = tool.FlagNames()
+		}
+	}
+
+	printMatches(candidates, cur)
+	return 0
+}
+
+// completeDirs lists directories starting with prefix (~ expanded for matching, kept in output)
+func completeDirs(prefix string) []string {
+	expanded := shared.ExpandHome(prefix)
+	matches, _ := filepath.Glob(expanded + "*")
+
+	var dirs []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			dirs = append(dirs, prefix+strings.TrimPrefix(match, expanded)+"/")
+		}
+	}
+	return dirs
+}
+
+// completeAgents lists opencode's built-in agents plus the markdown agents in the global
+// and project config dirs
+func completeAgents() []string {
+	agents := []string{"build\tBuilt-in", "plan\tBuilt-in", "general\tBuilt-in"}
+	home := os.Getenv("HOME")
+	for _, dir := range []string{
+		filepath.Join(home, ".config", "opencode", "agent"),
+		filepath.Join(home, ".config", "opencode", "agents"),
+		filepath.Join(".opencode", "agent"),
+		filepath.Join(".opencode", "agents"),
+	} {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+		for _, file := range files {
+			agents = append(agents, strings.TrimSuffix(filepath.Base(file), ".md")+"\t"+file)
+		}
+	}
+	return agents
+}
+
+// printMatches prints the candidates whose value starts with prefix
+func printMatches(candidates []string, prefix string) {
+	for _, candidate := range candidates {
+		value, _, _ := strings.Cut(candidate, "\t")
+		if strings.HasPrefix(value, prefix) {
+			fmt.Println(candidate)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+var validDBs = map[string]bool{"resources": true, "learning": true, "teaching": true}
+
+// Set by db-oracle's flags
+var (
+	dbOracleDB   string
+	dbOracleDirs []string
+)
+
+var dbOracle = &shared.Tool{
+	Name:    "db-oracle",
+	Summary: "Answer questions about the resources, learning and teaching databases",
+	Usage:   `Usage: echo "prompt" | db-oracle -db <resources|learning|teaching> [-s SESSION_ID] [-v] [--dirs <dir1> <dir2> ...]`,
+	Flags: `  -db <name>                  Database to query (required): resources, learning, or teaching
+  --dirs <dirs...>            Additional directories to reference in the prompt
+`,
+	Notes: "Note: Prompt must be provided via stdin (pipe or redirect)",
+	Examples: `  echo "List all courses" | db-oracle -db learning
+  cat query.txt | db-oracle -db teaching
+  cat prompt.txt | db-oracle -db resources --dirs backend/app frontend/src
+  db-oracle -db resources -s ses_abc123 <<< "continue with the query"`,
+	// Run from metarepo (db-oracle can take very long due to opus model)
+	WorkDir:      "~/Coding/metarepo",
+	Timeout:      30 * time.Minute,
+	Budget:       &shared.Budget{MaxCost: 10}, // Opus for half an hour adds up ([tools.db-oracle.budget] overrides)
+	Isolated:     true,
+	RequireStdin: true,
+	SetFlags: func(fs *flag.FlagSet) {
+		fs.StringVar(&dbOracleDB, "db", "", "Database to query: resources, learning, or teaching")
+	},
+	// Custom parsing for --dirs which takes multiple values
+	ParseArgs: func(args []string) []string {
+		var filteredArgs []string
+		for i := 0; i < len(args); i++ {
+			if args[i] == "--dirs" {
+				i++
+				for i < len(args) && !strings.HasPrefix(args[i], "-") {
+					dbOracleDirs = append(dbOracleDirs, args[i])
+					i++
+				}
+				i-- // Back up one since the loop will increment
+			} else {
+				filteredArgs = append(filteredArgs, args[i])
+			}
+		}
+		return filteredArgs
+	},
+	ArgFlags: []string{"--dirs"},
+	Prepare: func(inv *shared.Invocation) error {
+		inv.Log("Database: %s", dbOracleDB)
+		inv.Log("Directories: %v", dbOracleDirs)
+
+		if dbOracleDB == "" {
+			return fmt.Errorf("-db argument is required (you're currently in repo: '%s')", getRepoName())
+		}
+		if !validDBs[dbOracleDB] {
+			return fmt.Errorf("-db must be one of: resources, learning, teaching")
+		}
+
+		// Build final prompt with dirs context
+		if len(dbOracleDirs) > 0 {
+			var finalPrompt strings.Builder
+			finalPrompt.WriteString("Referenced Project/Repositories directories:\n")
+			for _, dir := range dbOracleDirs {
+				finalPrompt.WriteString(fmt.Sprintf("- %s\n", dir))
+			}
+			finalPrompt.WriteString("\n")
+			finalPrompt.WriteString(inv.Prompt)
+			inv.Prompt = finalPrompt.String()
+		}
+		return nil
+	},
+}
+
+func getRepoName() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "unknown"
+	}
+	return filepath.Base(wd)
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+
+	"tutero/oc-tools/shared"
+)
+
+const exportUsage = `Usage: oc sessions export SESSION_ID [--format md|json|html] [--reasoning] [-o FILE]
+
+Writes the session's transcript: prompts, answers, tool calls with their input and output,
+and timestamps. Tool calls (and reasoning) are collapsible sections in HTML, and in
+Markdown on GitHub.
+
+Options:
+  --format md|json|html       Output format (default md)
+  --reasoning                 Include the model's reasoning
+  -o, --output FILE           Write to FILE instead of stdout
+`
+
+// exportFormats maps --format values to their writers
+var exportFormats = map[string]func(io.Writer, *transcript) error{
+	"md":       writeMarkdown,
+	"markdown": writeMarkdown,
+	"json":     writeJSON,
+	"html":     writeHTML,
+}
+
+// transcript is a session as exported, independent of the server's message format
+type transcript struct {
+	Session  *shared.SessionRecord `json:"session"`
+	Exported time.Time             `json:"exported"`
+	Messages []transcriptMessage   `json:"messages"`
+}
+
+type transcriptMessage struct {
+	ID    string           `json:"id"`
+	Role  string           `json:"role"`            // user or assistant
+	Model string           `json:"model,omitempty"` // provider/model (assistant only)
+	Agent string           `json:"agent,omitempty"` // Assistant only
+	Time  time.Time        `json:"time"`
+	Parts []transcriptPart `json:"parts"`
+}
+
+type transcriptPart struct {
+	Type     string      `json:"type"` // text, reasoning, tool or file
+	Text     string      `json:"text,omitempty"`
+	Tool     string      `json:"tool,omitempty"`
+	Title    string      `json:"title,omitempty"`
+	Status   string      `json:"status,omitempty"`
+	Input    interface{} `json:"input,omitempty"`
+	Output   string      `json:"output,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Filename string      `json:"filename,omitempty"`
+	Mime     string      `json:"mime,omitempty"`
+	Started  *time.Time  `json:"started,omitempty"` // Tool calls only
+	Elapsed  string      `json:"elapsed,omitempty"` // Finished tool calls only
+}
+
+func sessionsExport(args []string) int {
+	// Flags may come after the session ID
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, exportUsage) }
+	format := fs.String("format", "md", "Output format")
+	reasoning := fs.Bool("reasoning", false, "Include reasoning")
+	var output string
+	fs.StringVar(&output, "output", "", "Write to FILE")
+	fs.StringVar(&output, "o", "", "Write to FILE")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if id == "" && fs.NArg() == 1 {
+		id = fs.Arg(0)
+	} else if id == "" || fs.NArg() > 0 {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return 2
+	}
+	write, ok := exportFormats[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (want md, json or html)\n", *format)
+		return 2
+	}
+
+	rec, code := loadRecord(id)
+	if rec == nil {
+		return code
+	}
+	client, cancel := sessionClient(rec)
+	defer cancel()
+	defer client.Close()
+	messages, err := client.Session.Messages(context.Background(), rec.ID, opencode.SessionMessagesParams{
+		Directory: opencode.F(rec.WorkDir),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not fetch transcript: %v\n", err)
+		return 1
+	}
+
+	t := &transcript{Session: rec, Exported: time.Now(), Messages: []transcriptMessage{}}
+	for _, message := range *messages {
+		t.Messages = append(t.Messages, newTranscriptMessage(message, *reasoning))
+	}
+
+	out := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(shared.ExpandHome(output))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := write(out, t); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %s to %s\n", rec.ID, output)
+	}
+	return 0
+}
+
+// newTranscriptMessage converts a server message, dropping bookkeeping parts (steps,
+// snapshots, patches) and reasoning unless asked for
+func newTranscriptMessage(message opencode.SessionMessagesResponse, reasoning bool) transcriptMessage {
+	var m transcriptMessage
+	switch info := message.Info.AsUnion().(type) {
+	case opencode.UserMessage:
+		m = transcriptMessage{ID: info.ID, Role: "user", Time: millis(info.Time.Created)}
+	case opencode.AssistantMessage:
+		m = transcriptMessage{ID: info.ID, Role: "assistant", Model: info.ProviderID + "/" + info.ModelID,
+			Agent: info.Mode, Time: millis(info.Time.Created)}
+	}
+	m.Parts = []transcriptPart{}
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case opencode.PartTypeText:
+			if part.Synthetic || strings.TrimSpace(part.Text) == "" {
+				continue
+			}
+			m.Parts = append(m.Parts, transcriptPart{Type: "text", Text: part.Text})
+		case opencode.PartTypeReasoning:
+			if reasoning && strings.TrimSpace(part.Text) != "" {
+				m.Parts = append(m.Parts, transcriptPart{Type: "reasoning", Text: part.Text})
+			}
+		case opencode.PartTypeFile:
+			m.Parts = append(m.Parts, transcriptPart{Type: "file", Filename: part.Filename, Mime: part.Mime})
+		case opencode.PartTypeTool:
+			m.Parts = append(m.Parts, newToolPart(part))
+		}
+	}
+	return m
+}
+
+func newToolPart(part opencode.Part) transcriptPart {
+	p := transcriptPart{Type: "tool", Tool: part.Tool}
+	state, ok := part.State.(opencode.ToolPartState)
+	if !ok {
+		return p
+	}
+	p.Title, p.Status, p.Input, p.Output, p.Error = state.Title, string(state.Status), state.Input, state.Output, state.Error
+
+	var start, end float64
+	switch s := state.AsUnion().(type) {
+	case opencode.ToolStateCompleted:
+		start, end = s.Time.Start, s.Time.End
+	case opencode.ToolStateError:
+		start, end = s.Time.Start, s.Time.End
+	case opencode.ToolStateRunning:
+		start = s.Time.Start
+	}
+	if start > 0 {
+		started := millis(start)
+		p.Started = &started
+	}
+	if start > 0 && end > start {
+		p.Elapsed = (time.Duration(end-start) * time.Millisecond).Round(100 * time.Millisecond).String()
+	}
+	return p
+}
+
+// millis converts a server time (Unix milliseconds)
+func millis(ms float64) time.Time {
+	return time.UnixMilli(int64(ms))
+}
+
+// Summary is the one-line heading of a tool call
+func (p transcriptPart) Summary() string {
+	s := p.Tool
+	if p.Title != "" {
+		s += ": " + p.Title
+	}
+	var details []string
+	if p.Status != "" {
+		details = append(details, p.Status)
+	}
+	if p.Elapsed != "" {
+		details = append(details, p.Elapsed)
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// InputJSON is the tool call's input, indented ("" if none)
+func (p transcriptPart) InputJSON() string {
+	if p.Input == nil {
+		return ""
+	}
+	data, err := json.MarshalIndent(p.Input, "", "  ")
+	if err != nil || string(data) == "{}" || string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+func writeJSON(w io.Writer, t *transcript) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// writeMarkdown writes GitHub-flavoured Markdown; tool calls and reasoning go in
+// <details> so a pasted consultation stays readable
+func writeMarkdown(w io.Writer, t *transcript) error {
+	var b strings.Builder
+	rec := t.Session
+	fmt.Fprintf(&b, "# %s\n\n", orDefault(rec.Title, rec.ID))
+	fmt.Fprintf(&b, "- **Session:** `%s`\n", rec.ID)
+	fmt.Fprintf(&b, "- **Tool:** %s\n", rec.Tool)
+	if rec.Model != "" {
+		fmt.Fprintf(&b, "- **Model:** %s\n", rec.Model)
+	}
+	fmt.Fprintf(&b, "- **Workdir:** `%s`\n", rec.WorkDir)
+	if rec.Branch != "" {
+		fmt.Fprintf(&b, "- **Branch:** `%s`\n", rec.Branch)
+	}
+	fmt.Fprintf(&b, "- **Created:** %s\n", rec.Created.Local().Format("2006-01-02 15:04:05"))
+
+	for _, m := range t.Messages {
+		heading := "User"
+		if m.Role == "assistant" {
+			heading = "Assistant · " + m.Model
+		}
+		fmt.Fprintf(&b, "\n## %s · %s\n", heading, m.Time.Local().Format("2006-01-02 15:04:05"))
+
+		for _, p := range m.Parts {
+			b.WriteString("\n")
+			switch p.Type {
+			case "text":
+				b.WriteString(strings.TrimRight(p.Text, "\n") + "\n")
+			case "reasoning":
+				b.WriteString("<details>\n<summary>Reasoning</summary>\n\n")
+				b.WriteString(strings.TrimRight(p.Text, "\n") + "\n\n</details>\n")
+			case "file":
+				fmt.Fprintf(&b, "📎 `%s`\n", p.Filename)
+			case "tool":
+				fmt.Fprintf(&b, "<details>\n<summary>🔧 %s</summary>\n\n", template.HTMLEscapeString(p.Summary()))
+				if input := p.InputJSON(); input != "" {
+					b.WriteString("**Input**\n\n" + codeBlock("json", input))
+				}
+				if p.Output != "" {
+					b.WriteString("**Output**\n\n" + codeBlock("", p.Output))
+				}
+				if p.Error != "" {
+					b.WriteString("**Error**\n\n" + codeBlock("", p.Error))
+				}
+				b.WriteString("</details>\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// codeBlock fences text with more backticks than it contains in a row
+func codeBlock(lang, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n\n"
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// exportHTML is a standalone page: no external styles or scripts, so it can be attached
+// or opened anywhere
+var exportHTML = template.Must(template.New("export").Funcs(template.FuncMap{
+	"stamp": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{or .Session.Title .Session.ID}}</title>
+<style>
+body { font: 15px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+h1 { font-size: 1.6em; margin-bottom: .3em; }
+dl.meta { display: grid; grid-template-columns: max-content 1fr; gap: .1em 1em; color: #59636e; }
+dl.meta dt { font-weight: 600; }
+dl.meta dd { margin: 0; }
+.message { border: 1px solid #d1d9e0; border-radius: 6px; margin: 1.2em 0; }
+.message > header { padding: .4em .8em; border-bottom: 1px solid #d1d9e0; font-size: .9em; color: #59636e; }
+.message.user > header { background: #ddf4ff; }
+.message.assistant > header { background: #f6f8fa; }
+.message > .body { padding: .4em .8em; }
+.text { white-space: pre-wrap; overflow-wrap: anywhere; margin: .6em 0; }
+details { border: 1px solid #d1d9e0; border-radius: 6px; margin: .6em 0; }
+details > summary { cursor: pointer; padding: .3em .6em; background: #f6f8fa; font-family: ui-monospace, monospace; font-size: .9em; }
+details.reasoning > summary { font-family: inherit; font-style: italic; }
+details > div { padding: 0 .6em; }
+details.error > summary { color: #d1242f; }
+pre { background: #f6f8fa; padding: .6em; border-radius: 6px; overflow-x: auto; font-size: .85em; white-space: pre-wrap; }
+.label { font-weight: 600; font-size: .85em; margin-top: .6em; }
+.file { font-family: ui-monospace, monospace; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>{{or .Session.Title .Session.ID}}</h1>
+<dl class="meta">
+<dt>Session</dt><dd>{{.Session.ID}}</dd>
+<dt>Tool</dt><dd>{{.Session.Tool}}</dd>
+{{- with .Session.Model}}
+<dt>Model</dt><dd>{{.}}</dd>
+{{- end}}
+<dt>Workdir</dt><dd>{{.Session.WorkDir}}</dd>
+{{- with .Session.Branch}}
+<dt>Branch</dt><dd>{{.}}</dd>
+{{- end}}
+<dt>Created</dt><dd>{{stamp .Session.Created}}</dd>
+<dt>Exported</dt><dd>{{stamp .Exported}}</dd>
+</dl>
+{{range .Messages}}
+<section class="message {{.Role}}">
+<header>{{if eq .Role "user"}}User{{else}}Assistant · {{.Model}}{{end}} · {{stamp .Time}}</header>
+<div class="body">
+{{- range .Parts}}
+{{- if eq .Type "text"}}
+<div class="text">{{.Text}}</div>
+{{- else if eq .Type "reasoning"}}
+<details class="reasoning"><summary>Reasoning</summary><div class="text">{{.Text}}</div></details>
+{{- else if eq .Type "file"}}
+<p class="file">📎 {{.Filename}}</p>
+{{- else if eq .Type "tool"}}
+<details class="tool{{if .Error}} error{{end}}"><summary>🔧 {{.Summary}}</summary><div>
+{{- with .InputJSON}}
+<div class="label">Input</div><pre>{{.}}</pre>
+{{- end}}
+{{- with .Output}}
+<div class="label">Output</div><pre>{{.}}</pre>
+{{- end}}
+{{- with .Error}}
+<div class="label">Error</div><pre>{{.}}</pre>
+{{- end}}
+</div></details>
+{{- end}}
+{{- end}}
+</div>
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, t *transcript) error {
+	return exportHTML.Execute(w, t)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+
+	"tutero/oc-tools/shared"
+)
+
+func TestCodeBlock(t *testing.T) {
+	tests := []struct {
+		lang, text string
+		want       string
+	}{
+		{"json", "{}", "```json\n{}\n```\n\n"},
+		{"", "out\n\n", "```\nout\n```\n\n"},
+		{"", "use `x`", "```\nuse `x`\n```\n\n"},
+		{"md", "```go\nx\n```", "````md\n```go\nx\n```\n````\n\n"},
+		{"", "`````", "``````\n`````\n``````\n\n"},
+	}
+	for _, tt := range tests {
+		if got := codeBlock(tt.lang, tt.text); got != tt.want {
+			t.Errorf("codeBlock(%q, %q) = %q, want %q", tt.lang, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTranscriptPartSummary(t *testing.T) {
+	tests := []struct {
+		part transcriptPart
+		want string
+	}{
+		{transcriptPart{Tool: "read"}, "read"},
+		{transcriptPart{Tool: "read", Title: "main.go", Status: "completed", Elapsed: "1.2s"}, "read: main.go (completed, 1.2s)"},
+		{transcriptPart{Tool: "bash", Status: "running"}, "bash (running)"},
+	}
+	for _, tt := range tests {
+		if got := tt.part.Summary(); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+
+	for input, want := range map[interface{}]string{
+		nil:              "",
+		"":               `""`,
+		"ls -la":         `"ls -la"`,
+		json.Number("1"): "1",
+	} {
+		if got := (transcriptPart{Input: input}).InputJSON(); got != want {
+			t.Errorf("InputJSON(%v) = %q, want %q", input, got, want)
+		}
+	}
+	if got := (transcriptPart{Input: map[string]interface{}{}}).InputJSON(); got != "" {
+		t.Errorf("InputJSON({}) = %q, want none", got)
+	}
+}
+
+func TestNewTranscriptMessage(t *testing.T) {
+	var message opencode.SessionMessagesResponse
+	err := json.Unmarshal([]byte(`{
+		"info": {"id": "msg_a", "role": "assistant", "sessionID": "ses_1", "providerID": "anthropic",
+			"modelID": "claude-opus-4-1", "mode": "plan", "time": {"created": 1700000000000}},
+		"parts": [
+			{"id": "p1", "type": "step-start"},
+			{"id": "p2", "type": "reasoning", "text": "thinking"},
+			{"id": "p3", "type": "text", "text": "injected", "synthetic": true},
+			{"id": "p4", "type": "text", "text": "  "},
+			{"id": "p5", "type": "tool", "tool": "read", "callID": "c1", "state": {"status": "completed",
+				"input": {"filePath": "main.go"}, "output": "package main", "title": "main.go",
+				"metadata": {}, "time": {"start": 1700000001000, "end": 1700000002200}}},
+			{"id": "p6", "type": "text", "text": "Done"}
+		]
+	}`), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTranscriptMessage(message, false)
+	if m.Role != "assistant" || m.Model != "anthropic/claude-opus-4-1" || m.Agent != "plan" {
+		t.Errorf("message = %+v, want an assistant message from anthropic/claude-opus-4-1 as plan", m)
+	}
+	var types []string
+	for _, p := range m.Parts {
+		types = append(types, p.Type)
+	}
+	if got := strings.Join(types, " "); got != "tool text" {
+		t.Fatalf("parts = %s, want tool text", got)
+	}
+	if tool := m.Parts[0]; tool.Summary() != "read: main.go (completed, 1.2s)" || tool.Output != "package main" {
+		t.Errorf("tool part = %+v", tool)
+	}
+
+	if got := newTranscriptMessage(message, true).Parts[0]; got.Type != "reasoning" || got.Text != "thinking" {
+		t.Errorf("with reasoning, first part = %+v, want the reasoning", got)
+	}
+}
+
+func TestWriteTranscript(t *testing.T) {
+	created := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	tr := &transcript{
+		Session:  &shared.SessionRecord{ID: "ses_1", Tool: "big-brain", WorkDir: "/tmp/proj", Created: created},
+		Exported: created,
+		Messages: []transcriptMessage{
+			{ID: "msg_1", Role: "user", Time: created, Parts: []transcriptPart{
+				{Type: "text", Text: "Is <b>this</b> safe?"},
+				{Type: "file", Filename: "main.go", Mime: "text/plain"},
+			}},
+			{ID: "msg_2", Role: "assistant", Model: "anthropic/claude-opus-4-1", Time: created, Parts: []transcriptPart{
+				{Type: "tool", Tool: "bash", Status: "error", Error: "exit 1\n```"},
+				{Type: "text", Text: "Yes"},
+			}},
+		},
+	}
+
+	var md bytes.Buffer
+	if err := writeMarkdown(&md, tr); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# ses_1\n", "- **Tool:** big-brain\n", "Is <b>this</b> safe?\n", "📎 `main.go`",
+		"## Assistant · anthropic/claude-opus-4-1", "<summary>🔧 bash (error)</summary>", "````\nexit 1\n```\n````\n"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown is missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := writeHTML(&html, tr); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>ses_1</title>", "Is &lt;b&gt;this&lt;/b&gt; safe?", `<details class="tool error">`} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML is missing %q:\n%s", want, html.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, tr); err != nil {
+		t.Fatal(err)
+	}
+	var back transcript
+	if err := json.Unmarshal(out.Bytes(), &back); err != nil || len(back.Messages) != 2 || back.Messages[1].Parts[1].Text != "Yes" {
+		t.Errorf("JSON does not round-trip: %v\n%s", err, out.String())
+	}
+}
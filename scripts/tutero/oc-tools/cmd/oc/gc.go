@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+const gcUsage = `Usage: oc gc [--dry-run] [--tool NAME] [--max-age 30d] [--no-sessions] [--json]
+   or: oc-gc [options]
+
+Removes what tools leave behind in ~/.cache/scripts: logs unused for longer than the
+retention, the oldest logs past a tool's size cap, session-*.log links whose log is gone,
+and expired sessions (deleted on their server and from the session registry).
+
+Retention comes from config.toml, per tool if set:
+  auto_gc = true              Run oc gc in the background at most once a day when a tool
+                              starts (report in ~/.cache/scripts/oc-tools/gc.log); a
+                              top-level key, so above the first [table]
+  [gc]                        max_age = "30d", max_size_mb = 0 (no cap)
+  [tools.NAME.gc]             Overrides for one tool
+
+Options:
+  -n, --dry-run               Report what would be removed without removing it
+  --tool NAME                 Only this tool's logs and sessions
+  --max-age AGE               Override every tool's max_age (e.g. 7d, 12h; 0 = keep)
+  --no-sessions               Leave sessions alone (no server is started)
+  --json                      Print JSON instead of a table
+  -h, --help                  Show this help message
+`
+
+// gcCommand implements `oc gc`
+func gcCommand(args []string) int {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, gcUsage) }
+	var dryRun bool
+	fs.BoolVar(&dryRun, "dry-run", false, "Report without removing")
+	fs.BoolVar(&dryRun, "n", false, "Report without removing")
+	toolName := fs.String("tool", "", "Only this tool")
+	var maxAge shared.Duration
+	maxAgeSet := false
+	fs.Func("max-age", "Override max_age", func(s string) error {
+		maxAgeSet = true
+		return maxAge.Set(s)
+	})
+	noSessions := fs.Bool("no-sessions", false, "Leave sessions alone")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	// Started by MaybeAutoGC: report only when something was removed
+	auto := fs.Bool("auto", false, "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	cfg := shared.LoadConfig()
+	policyFor := func(tool string) shared.GCPolicy {
+		policy := cfg.GCPolicy(tool)
+		if maxAgeSet {
+			policy.MaxAge = &maxAge
+		}
+		return policy
+	}
+
+	var items []shared.GCItem
+	for _, name := range gcTools() {
+		if *toolName != "" && name != *toolName {
+			continue
+		}
+		items = append(items, shared.GCLogs(name, policyFor(name), dryRun)...)
+	}
+	if !*noSessions {
+		items = append(items, gcSessions(policyFor, *toolName, dryRun)...)
+	}
+
+	if *asJSON {
+		if items == nil {
+			items = []shared.GCItem{}
+		}
+		out := struct {
+			DryRun bool            `json:"dry_run"`
+			Items  []shared.GCItem `json:"items"`
+		}{dryRun, items}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return gcStatus(items)
+	}
+	if len(items) == 0 {
+		if !*auto {
+			fmt.Fprintln(os.Stderr, "Nothing to remove")
+		}
+		return 0
+	}
+	if *auto {
+		fmt.Printf("== oc gc --auto %s ==\n", time.Now().Format("2006-01-02 15:04:05"))
+	}
+	printGCReport(items, dryRun)
+	return gcStatus(items)
+}
+
+// gcTools returns every tool that may have a log dir: built-ins and plugins
+func gcTools() []string {
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	for _, p := range loadPlugins() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// gcSessions expires sessions on the main server, then on the isolated one
+// Each server is only started if the registry has sessions on it (of toolName, if set);
+// the isolated server comes last because IsolateDataDir changes this process's
+// environment for good
+func gcSessions(policyFor func(string) shared.GCPolicy, toolName string, dryRun bool) []shared.GCItem {
+	records, _ := shared.ListSessionRecords()
+	onServer := map[bool]bool{}
+	for _, rec := range records {
+		if toolName == "" || rec.Tool == toolName {
+			onServer[rec.Isolated] = true
+		}
+	}
+
+	var items []shared.GCItem
+	for _, isolated := range []bool{false, true} {
+		if !onServer[isolated] {
+			continue
+		}
+		if isolated {
+			shared.IsolateDataDir()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		client := shared.NewClient(ctx)
+		items = append(items, client.GCSessions(policyFor, toolName, isolated, dryRun)...)
+		client.Close()
+		cancel()
+	}
+	return items
+}
+
+func printGCReport(items []shared.GCItem, dryRun bool) {
+	action := "removed"
+	if dryRun {
+		action = "would remove"
+	}
+
+	home := os.Getenv("HOME")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tKIND\tTOOL\tTARGET\tSIZE\tREASON")
+	var freed int64
+	counts := map[string]int{}
+	for _, item := range items {
+		status := action
+		if item.Error != "" {
+			status = "failed"
+		} else {
+			freed += item.Size
+			counts[item.Kind]++
+		}
+		target := item.Target
+		if home != "" && strings.HasPrefix(target, home+string(filepath.Separator)) {
+			target = "~" + strings.TrimPrefix(target, home)
+		}
+		reason := item.Reason
+		if item.Error != "" {
+			reason += ": " + item.Error
+		}
+		size := "-"
+		if item.Size > 0 {
+			size = formatSize(item.Size)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", status, item.Kind, orDash(item.Tool), target, size, reason)
+	}
+	w.Flush()
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("\n%s %d logs (%s), %d links, %d sessions\n", verb,
+		counts["log"], formatSize(freed), counts["link"], counts["session"])
+}
+
+// gcStatus is 1 if anything failed to be removed
+func gcStatus(items []shared.GCItem) int {
+	for _, item := range items {
+		if item.Error != "" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// formatSize shows a byte count in KB/MB/GB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+var localLibrarian = &shared.Tool{
+	Name:    "local-librarian",
+	Summary: "Search local repositories and explain what it finds",
+	Usage: `Usage: local-librarian [-s SESSION_ID] [-v]
+   or: echo "prompt" | local-librarian [-s SESSION_ID]
+   or: local-librarian [-s SESSION_ID] <<EOF
+       prompt text here
+       EOF`,
+	Notes: `Note: Prompt must be provided via stdin (pipe or redirect)
+      Always specify the directory to search in your prompt!`,
+	Examples: `  echo "Search ~/Coding/mathgaps-org to find how spans and logs are uploaded to Grafana" | local-librarian
+
+  local-librarian <<EOF
+  Search ~/Coding/myproject to find authentication logic.
+  Look for middleware and JWT validation.
+  EOF
+
+  local-librarian -s ses_abc123 <<< "continue searching for related code"`,
+	// Run from $HOME for read-only access to all repos
+	WorkDir:      "~",
+	Timeout:      15 * time.Minute,
+	Isolated:     true,
+	RequireStdin: true,
+	Prepare: func(inv *shared.Invocation) error {
+		// Prepend invocation directory context (fallback if no dir specified)
+		inv.Prompt = fmt.Sprintf("Invoked Dir (CWD): %s\n(use as fallback if no dir specified to search)\n\n", inv.InvokeDir) + inv.Prompt
+		return nil
+	},
+}
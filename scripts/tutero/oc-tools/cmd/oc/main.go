@@ -0,0 +1,232 @@
+// oc is the single binary behind every oc-tools command
+// Run it as `oc <tool> ...` or through a symlink named after the tool (busybox style):
+//
+//	oc big-brain "How should we refactor the auth system?"
+//	big-brain "How should we refactor the auth system?"   # big-brain -> oc
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tutero/oc-tools/shared"
+)
+
+// tools lists every built-in command, in help order
+var tools = []*shared.Tool{
+	bigBrain,
+	webSearch,
+	localLibrarian,
+	sessionHunter,
+	dbOracle,
+	branchNamer,
+	ocRun,
+}
+
+// command is one of oc's own subcommands (not an agent), also runnable through a
+// symlink named oc-<name>
+type command struct {
+	Name    string
+	Summary string
+	Usage   string
+	Flags   []string // For completion
+	Run     func(args []string) int
+
+	// Complete returns completion candidates given the words after the command name
+	// (nil = complete Flags)
+	Complete func(rest []string, cur string) []string
+}
+
+// commands lists oc's own subcommands, in help order
+var commands = []*command{
+	{
+		Name:    "models",
+		Summary: "List models and the one each tool would pick",
+		Usage:   modelsUsage,
+		Flags:   []string{"--free", "--provider", "--tool-call", "--json", "--refresh", "--help"},
+		Run:     modelsCommand,
+	},
+	{
+		Name:    "usage",
+		Summary: "Report tokens and cost of past runs",
+		Usage:   usageUsage,
+		Flags:   []string{"--by", "--since", "--tool", "--json", "--help"},
+		Run:     usageCommand,
+	},
+	{
+		Name:     "sessions",
+		Summary:  "List, show, resume or delete the sessions tools created",
+		Usage:    sessionsUsage,
+		Run:      sessionsCommand,
+		Complete: completeSessions,
+	},
+	{
+		Name:    "gc",
+		Summary: "Remove old logs, dangling links and expired sessions",
+		Usage:   gcUsage,
+		Flags:   []string{"--dry-run", "--tool", "--max-age", "--no-sessions", "--json", "--help"},
+		Run:     gcCommand,
+	},
+}
+
+const usage = `Usage: oc <command> [options] ["prompt"]
+   or: <command> [options] ["prompt"]    (via a symlink named after the command)
+
+Commands:
+%s
+  completion bash|zsh|fish    Print a shell completion script
+  help [command]              Show help for oc or a command
+%s
+Options:
+  --parent SESSION_ID         Create new sessions as children of this one
+                              (passed to external tools as OC_TOOLS_PARENT_SESSION)
+  --daemon                    Run the keep-warm server daemon in the foreground
+  -h, --help                  Show this help message
+
+Run 'oc <command> --help' for the options of a command.
+External tools: oc-tool-<name> executables on PATH, or manifests in %s
+`
+
+func main() {
+	os.Exit(run(filepath.Base(os.Args[0]), os.Args[1:]))
+}
+
+// run dispatches on the invoked name first (symlink), then on the first argument
+// Built-ins are looked up before plugins, which take a scan of PATH to find
+func run(name string, args []string) int {
+	if tool := findBuiltin(name); tool != nil {
+		return tool.Execute(args)
+	}
+	if cmd := findCommand(name); cmd != nil {
+		return cmd.Run(args)
+	}
+	if name != "oc" {
+		if p := findPlugin(name); p != nil && p.Tool != nil {
+			return p.Tool.Execute(args)
+		}
+	}
+
+	// --parent only makes sense before the command: `oc --parent ses_abc web-search ...`
+	for len(args) > 0 && (args[0] == "--parent" || strings.HasPrefix(args[0], "--parent=")) {
+		parent, ok := strings.CutPrefix(args[0], "--parent=")
+		args = args[1:]
+		if !ok {
+			if len(args) == 0 {
+				fmt.Fprintln(os.Stderr, "Error: --parent requires a session ID")
+				return 2
+			}
+			parent, args = args[0], args[1:]
+		}
+		os.Setenv(shared.EnvParentSession, parent)
+	}
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, help())
+		return 2
+	}
+
+	switch args[0] {
+	case "-h", "--help":
+		fmt.Fprint(os.Stderr, help())
+		return 0
+	case "help":
+		if len(args) > 1 {
+			if tool := findBuiltin(args[1]); tool != nil {
+				fmt.Fprint(os.Stderr, tool.Help())
+				return 0
+			}
+			if cmd := findCommand(args[1]); cmd != nil {
+				fmt.Fprint(os.Stderr, cmd.Usage)
+				return 0
+			}
+			if p := findPlugin(args[1]); p != nil {
+				if p.Tool != nil {
+					fmt.Fprint(os.Stderr, p.Tool.Help())
+					return 0
+				}
+				// External commands document themselves
+				return p.run([]string{"--help"})
+			}
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", args[1])
+			return 2
+		}
+		fmt.Fprint(os.Stderr, help())
+		return 0
+	case "--daemon":
+		// Spawned by a tool's Client via os.Executable, which resolves the symlink to oc
+		shared.HandleDaemonFlag(args)
+		return 0
+	case "completion":
+		return completion(args[1:])
+	case completeCommand:
+		return complete(args[1:])
+	}
+
+	if tool := findBuiltin(args[0]); tool != nil {
+		return tool.Execute(args[1:])
+	}
+	if cmd := findCommand(args[0]); cmd != nil {
+		return cmd.Run(args[1:])
+	}
+	if p := findPlugin(args[0]); p != nil {
+		return p.run(args[1:])
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", args[0])
+	fmt.Fprint(os.Stderr, help())
+	return 2
+}
+
+// findBuiltin returns the built-in agent tool called name (or one of its aliases), or nil
+func findBuiltin(name string) *shared.Tool {
+	for _, tool := range tools {
+		if tool.Is(name) {
+			return tool
+		}
+	}
+	return nil
+}
+
+// findTool returns the built-in or manifest-declared agent tool called name, or nil
+// Plugins are only loaded for a name that isn't built in
+func findTool(name string) *shared.Tool {
+	if tool := findBuiltin(name); tool != nil {
+		return tool
+	}
+	if p := findPlugin(name); p != nil {
+		return p.Tool
+	}
+	return nil
+}
+
+// findCommand returns oc's own subcommand called name (or oc-name), or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if name == cmd.Name || name == "oc-"+cmd.Name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func help() string {
+	var lines []string
+	for _, tool := range tools {
+		names := strings.Join(append([]string{tool.Name}, tool.Aliases...), ", ")
+		lines = append(lines, fmt.Sprintf("  %-28s%s", names, tool.Summary))
+	}
+	for _, cmd := range commands {
+		lines = append(lines, fmt.Sprintf("  %-28s%s", cmd.Name, cmd.Summary))
+	}
+
+	var external string
+	if found := loadPlugins(); len(found) > 0 {
+		external = "\nExternal tools:\n"
+		for _, p := range found {
+			external += fmt.Sprintf("  %-28s%s\n", p.Name, p.Summary)
+		}
+	}
+	return fmt.Sprintf(usage, strings.Join(lines, "\n"), external, pluginManifestDir())
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindToolLoadsPluginsLast(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, pluginPrefix+"probe"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No manifests
+
+	for _, name := range []string{"big-brain", "run", "web-search"} {
+		if findTool(name) == nil {
+			t.Errorf("findTool(%q) = nil, want the built-in", name)
+		}
+	}
+	if findCommand("oc-gc") == nil {
+		t.Error(`findCommand("oc-gc") = nil`)
+	}
+	if plugins != nil {
+		t.Fatalf("looking up built-ins loaded plugins: %v", plugins)
+	}
+
+	if p := findPlugin("probe"); p == nil || p.Command != filepath.Join(dir, pluginPrefix+"probe") {
+		t.Errorf("findPlugin(probe) = %+v, want the PATH executable", p)
+	}
+}
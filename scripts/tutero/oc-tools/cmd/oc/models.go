@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+const modelsUsage = `Usage: oc models [--free] [--provider NAME[,NAME]] [--tool-call] [--json] [--refresh]
+   or: oc-models [options]
+
+Lists every model the opencode server offers with its cost, context window, tool-call
+support and release date, then the model each tool would run with right now.
+
+Options:
+  --free                      Only free models
+  --provider NAME[,NAME]      Only these providers
+  --tool-call                 Only models that can call tools
+  --json                      Print JSON instead of tables
+  --refresh                   Ignore the cached model list (kept for model_cache_ttl)
+  -h, --help                  Show this help message
+`
+
+// toolPick is the model chain a tool would use, as `oc models` reports it
+type toolPick struct {
+	Tool      string   `json:"tool"`
+	Model     string   `json:"model"`
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	Source    string   `json:"source"` // config, policy, fallback_model or agent default
+	Policy    string   `json:"policy,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// modelsCommand implements `oc models`
+func modelsCommand(args []string) int {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, modelsUsage) }
+	free := fs.Bool("free", false, "Only free models")
+	providers := fs.String("provider", "", "Only these providers")
+	toolCall := fs.Bool("tool-call", false, "Only models that can call tools")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	refresh := fs.Bool("refresh", false, "Ignore the cached model list")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Tools on the shared server pick from its own model list (its providers may be set
+	// up differently), asked first because IsolateDataDir below is for good; that server is
+	// only started if one of them has a policy to resolve
+	var mainClient *shared.Client
+	picked := toolPicks(false, func() *shared.Client {
+		if mainClient == nil {
+			mainClient = shared.NewClient(ctx)
+		}
+		return mainClient
+	})
+	if mainClient != nil {
+		mainClient.Close()
+	}
+
+	// Most tools run on the isolated server, so list what it offers
+	shared.IsolateDataDir()
+	client := shared.NewClient(ctx)
+	defer client.Close()
+
+	models, err := client.ListModels(*refresh)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// The filters are a policy like any other
+	filter := &shared.ModelPolicy{}
+	if *providers != "" {
+		filter.Providers = strings.Split(*providers, ",")
+	}
+	if *free {
+		zero := 0.0
+		filter.MaxInputCost, filter.MaxOutputCost = &zero, &zero
+	}
+	if *toolCall {
+		filter.Require = []string{"tool_call"}
+	}
+	var listed []shared.ModelInfo
+	for _, m := range models {
+		if filter.Allows(m) {
+			listed = append(listed, m)
+		}
+	}
+
+	for name, pick := range toolPicks(true, func() *shared.Client { return client }) {
+		picked[name] = pick
+	}
+	var picks []toolPick
+	for _, tool := range agentTools() {
+		picks = append(picks, picked[tool.Name])
+	}
+
+	if *asJSON {
+		out := struct {
+			Models []shared.ModelInfo `json:"models"`
+			Picks  []toolPick         `json:"picks"`
+		}{listed, picks}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tINPUT $/M\tOUTPUT $/M\tCONTEXT\tTOOLS\tREASONING\tRELEASED")
+	for _, m := range listed {
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.ProviderID, m.ModelID,
+			formatCost(m.InputCost), formatCost(m.OutputCost), formatTokens(m.Context),
+			yesNo(m.ToolCall), yesNo(m.Reasoning), m.ReleaseDate)
+	}
+	w.Flush()
+	fmt.Printf("\n%d of %d models\n\n", len(listed), len(models))
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tPICKS\tFALLBACKS\tBECAUSE")
+	for _, pick := range picks {
+		because := pick.Source
+		if pick.Policy != "" {
+			because += " (" + pick.Policy + ")"
+		}
+		if pick.Error != "" {
+			because = "error: " + pick.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pick.Tool, pick.Model, strings.Join(pick.Fallbacks, ", "), because)
+	}
+	w.Flush()
+	return 0
+}
+
+// agentTools returns the built-in agent tools and those declared by plugin manifests
+func agentTools() []*shared.Tool {
+	all := append([]*shared.Tool{}, tools...)
+	for _, p := range loadPlugins() {
+		if p.Tool != nil {
+			all = append(all, p.Tool)
+		}
+	}
+	return all
+}
+
+// toolPicks resolves the model chain of the agent tools on one server (isolated or the
+// shared one) with the same rules the runner uses (config, then policy, then
+// fallback_model), keyed by tool. client is only called for a tool with a policy, whose
+// picks come from the models that server offers.
+func toolPicks(isolated bool, client func() *shared.Client) map[string]toolPick {
+	picks := map[string]toolPick{}
+	for _, tool := range agentTools() {
+		if tool.Isolated != isolated {
+			continue
+		}
+		settings := tool.Settings()
+		pick := toolPick{Tool: tool.Name}
+		var c *shared.Client
+		if !settings.Explicit() && tool.Policy(settings) != nil {
+			c = client()
+		}
+		chain, source, err := tool.ModelChain(settings, c)
+		if err != nil {
+			pick.Error = err.Error()
+			picks[tool.Name] = pick
+			continue
+		}
+
+		pick.Source = source
+		pick.Model = "(agent's own)"
+		if len(chain) > 0 {
+			pick.Model = chain[0].String()
+			for _, model := range chain[1:] {
+				pick.Fallbacks = append(pick.Fallbacks, model.String())
+			}
+		}
+		if source == "policy" || source == "fallback_model" {
+			if policy := tool.Policy(settings); policy != nil {
+				pick.Policy = policy.String()
+			}
+		}
+		picks[tool.Name] = pick
+	}
+	return picks
+}
+
+// formatCost shows USD per million tokens
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "free"
+	}
+	return fmt.Sprintf("%.2f", cost)
+}
+
+// formatTokens shows 200000 as 200k and 1000000 as 1M
+func formatTokens(n float64) string {
+	switch {
+	case n == 0:
+		return "-"
+	case n >= 1000000:
+		return fmt.Sprintf("%gM", n/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%gk", n/1000)
+	}
+	return fmt.Sprintf("%g", n)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+// Set by oc-run's flags
+var (
+	ocRunAgent      string
+	ocRunTools      = toolToggles{}
+	ocRunSystem     string
+	ocRunSystemFile string
+)
+
+// toolToggles collects repeated --tool name=on|off flags
+type toolToggles map[string]bool
+
+func (t toolToggles) String() string {
+	var pairs []string
+	for name, on := range t {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, on))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value: "websearch=on", "bash=off" ("websearch" alone means on)
+func (t toolToggles) Set(s string) error {
+	name, value, hasValue := strings.Cut(s, "=")
+	if name == "" {
+		return fmt.Errorf("expected name=on|off, got %q", s)
+	}
+	if !hasValue {
+		t[name] = true
+		return nil
+	}
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		t[name] = true
+	case "off", "false", "no", "0":
+		t[name] = false
+	default:
+		return fmt.Errorf("tool %s: expected on or off, got %q", name, value)
+	}
+	return nil
+}
+
+var ocRun = &shared.Tool{
+	Name:    "oc-run",
+	Aliases: []string{"run"},
+	Summary: "Run any agent with any model, tools and system prompt",
+	Usage: `Usage: oc-run [--agent NAME] [--model PROVIDER/MODEL] [--tool NAME=on|off ...] [--system-file FILE] "prompt"
+   or: echo "prompt" | oc run [options]`,
+	Description: `Runs ad-hoc or newly written agents without a dedicated command.
+Without --agent the session uses no agent, just the model and system prompt.`,
+	Flags: `  --agent NAME                opencode agent to run (e.g. build, plan, or ~/.config/opencode/agent/NAME.md)
+  --tool NAME=on|off          Enable or disable a tool for this run (repeatable)
+  --system TEXT               System prompt
+  --system-file FILE          Read the system prompt from FILE
+`,
+	Examples: `  oc run --agent plan "How would you split this package?"
+  oc-run --model anthropic/claude-haiku-4-5 --tool websearch=on "What changed in Go 1.23?"
+  oc-run --system-file reviewer.md --tool bash=off --tool edit=off < diff.patch
+  oc-run -s ses_abc123 "and the tests?"`,
+	Timeout:  10 * time.Minute,
+	Isolated: true,
+	SetFlags: func(fs *flag.FlagSet) {
+		fs.StringVar(&ocRunAgent, "agent", "", "Agent to run")
+		fs.Var(ocRunTools, "tool", "Enable or disable a tool: name=on|off")
+		fs.StringVar(&ocRunSystem, "system", "", "System prompt")
+		fs.StringVar(&ocRunSystemFile, "system-file", "", "File holding the system prompt")
+	},
+	Prepare: func(inv *shared.Invocation) error {
+		if ocRunSystem != "" && ocRunSystemFile != "" {
+			return fmt.Errorf("use either --system or --system-file, not both")
+		}
+		if ocRunSystemFile != "" {
+			data, err := os.ReadFile(shared.ExpandHome(ocRunSystemFile))
+			if err != nil {
+				return fmt.Errorf("failed to read system prompt: %w", err)
+			}
+			ocRunSystem = string(data)
+		}
+
+		// Without an agent the session title still says oc-run; NoAgent keeps it off the request
+		if ocRunAgent != "" {
+			inv.Agent = ocRunAgent
+		}
+		inv.Log("Agent: %q, tools: %v, system prompt: %d chars", ocRunAgent, ocRunTools, len(ocRunSystem))
+		return nil
+	},
+	Options: func(inv *shared.Invocation) (*shared.AgentOptions, error) {
+		opts := &shared.AgentOptions{
+			Model:   inv.Model,
+			System:  ocRunSystem,
+			NoAgent: ocRunAgent == "",
+		}
+		if len(ocRunTools) > 0 {
+			opts.Tools = ocRunTools
+		}
+		return opts, nil
+	},
+}
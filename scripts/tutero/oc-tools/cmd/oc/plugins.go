@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"tutero/oc-tools/shared"
+)
+
+// External tools extend oc without rebuilding it. Two kinds are discovered:
+//
+//   - Executables named oc-tool-<name> anywhere on PATH, run as `oc <name> args...`
+//
+//   - Manifests in ~/.config/oc-tools/tools.d/<name>.toml (or .json), either declaring
+//     an agent tool that runs through the shared runner, or wrapping a command:
+//
+//     # tools.d/project-oracle.toml
+//     summary       = "Answer questions about the project repo"
+//     agent         = "project-oracle"
+//     workdir       = "~/Coding/project"
+//     timeout       = "20m"
+//     require_stdin = true
+//
+//     # tools.d/db-oracle-oc.toml
+//     summary = "db-oracle against the opencode CLI"
+//     command = "~/bin/db-oracle-oc"
+//
+// External commands get the shared environment: OC_TOOLS_SERVER_URL (and OPENCODE_URL)
+// of a server leased for them, OC_TOOLS_PARENT_SESSION if oc itself was run on behalf of
+// a session, and OC_TOOLS_LOG_DIR (~/.cache/scripts/<name>). Built-in tools win on name clashes,
+// then manifests, then PATH order.
+const pluginPrefix = "oc-tool-"
+
+// pluginManifest is the on-disk form of a tools.d entry
+type pluginManifest struct {
+	Name         string              `toml:"name" json:"name"` // Defaults to the file name
+	Summary      string              `toml:"summary" json:"summary"`
+	Usage        string              `toml:"usage" json:"usage"`
+	Examples     string              `toml:"examples" json:"examples"`
+	Agent        string              `toml:"agent" json:"agent"` // Defaults to Name
+	WorkDir      string              `toml:"workdir" json:"workdir"`
+	PromptPrefix string              `toml:"prompt_prefix" json:"prompt_prefix"`
+	Timeout      shared.Duration     `toml:"timeout" json:"timeout"`
+	Model        string              `toml:"model" json:"model"`
+	Policy       *shared.ModelPolicy `toml:"policy" json:"policy"`     // Picks the model when none is set
+	Isolated     *bool               `toml:"isolated" json:"isolated"` // Default true
+	RequireStdin bool                `toml:"require_stdin" json:"require_stdin"`
+	Quiet        bool                `toml:"quiet" json:"quiet"`
+	AutoCleanup  bool                `toml:"auto_cleanup" json:"auto_cleanup"`
+	Command      string              `toml:"command" json:"command"` // Run this instead of an agent
+	Args         []string            `toml:"args" json:"args"`       // Prepended to the user's arguments
+}
+
+// plugin is a discovered external tool
+type plugin struct {
+	Name     string
+	Summary  string
+	Source   string       // Manifest file or executable it was found as
+	Command  string       // Executable to run (empty for agent tools)
+	Args     []string     // Leading arguments for Command
+	Isolated bool         // Lease the isolated server rather than the shared one
+	Tool     *shared.Tool // Agent tool declared by a manifest (nil for commands)
+}
+
+var (
+	plugins     []*plugin
+	pluginsOnce sync.Once
+)
+
+// pluginManifestDir returns where tool manifests live
+func pluginManifestDir() string {
+	return filepath.Join(shared.ConfigDir(), "tools.d")
+}
+
+// loadPlugins discovers external tools once per process
+// Broken manifests are reported on stderr and skipped
+func loadPlugins() []*plugin {
+	pluginsOnce.Do(func() {
+		seen := map[string]bool{}
+		for _, tool := range tools {
+			seen[tool.Name] = true
+			for _, alias := range tool.Aliases {
+				seen[alias] = true
+			}
+		}
+
+		add := func(p *plugin) {
+			if seen[p.Name] {
+				return
+			}
+			seen[p.Name] = true
+			plugins = append(plugins, p)
+		}
+
+		manifests, _ := filepath.Glob(filepath.Join(pluginManifestDir(), "*"))
+		sort.Strings(manifests)
+		for _, path := range manifests {
+			ext := filepath.Ext(path)
+			if ext != ".toml" && ext != ".json" {
+				continue
+			}
+			p, err := loadManifest(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[oc-tools] Warning: ignoring tool manifest %s: %v\n", path, err)
+				continue
+			}
+			add(p)
+		}
+
+		for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if !strings.HasPrefix(entry.Name(), pluginPrefix) || entry.Name() == pluginPrefix {
+					continue
+				}
+				path := filepath.Join(dir, entry.Name())
+				if !isExecutable(path) {
+					continue
+				}
+				add(&plugin{
+					Name:     strings.TrimPrefix(entry.Name(), pluginPrefix),
+					Summary:  "External tool " + path,
+					Source:   path,
+					Command:  path,
+					Isolated: true,
+				})
+			}
+		}
+	})
+	return plugins
+}
+
+// loadManifest reads a tools.d manifest into a plugin
+func loadManifest(path string) (*plugin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m pluginManifest
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &m)
+	} else {
+		_, err = toml.Decode(string(data), &m)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	isolated := m.Isolated == nil || *m.Isolated
+	summary := m.Summary
+	if summary == "" {
+		summary = "External tool " + path
+	}
+
+	p := &plugin{
+		Name:     m.Name,
+		Summary:  summary,
+		Source:   path,
+		Isolated: isolated,
+	}
+
+	if m.Command != "" {
+		p.Command = shared.ExpandHome(m.Command)
+		p.Args = m.Args
+		return p, nil
+	}
+
+	usage := m.Usage
+	if usage == "" {
+		usage = fmt.Sprintf("Usage: %s [-s SESSION_ID] [-v] \"prompt\"\n   or: echo \"prompt\" | %s [-s SESSION_ID]", m.Name, m.Name)
+	}
+	examples := m.Examples
+	if examples != "" && !strings.HasPrefix(examples, "  ") {
+		examples = "  " + strings.ReplaceAll(strings.TrimRight(examples, "\n"), "\n", "\n  ")
+	}
+
+	p.Tool = &shared.Tool{
+		Name:         m.Name,
+		Agent:        m.Agent,
+		Summary:      summary,
+		Usage:        usage,
+		Notes:        "Declared in " + path,
+		Examples:     examples,
+		WorkDir:      m.WorkDir,
+		PromptPrefix: m.PromptPrefix,
+		Timeout:      time.Duration(m.Timeout),
+		Model:        m.Model,
+		ModelPolicy:  m.Policy,
+		Isolated:     isolated,
+		RequireStdin: m.RequireStdin,
+		Quiet:        m.Quiet,
+		AutoCleanup:  m.AutoCleanup,
+	}
+	return p, nil
+}
+
+// findPlugin returns the external tool called name, or nil
+func findPlugin(name string) *plugin {
+	for _, p := range loadPlugins() {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// run executes the tool: agent manifests through the shared runner, commands as a child process
+func (p *plugin) run(args []string) int {
+	if p.Tool != nil {
+		return p.Tool.Execute(args)
+	}
+
+	if p.Isolated {
+		shared.IsolateDataDir()
+	}
+
+	// Lease a server for the whole run so the command (and any oc tools it calls) can reuse it
+	client := shared.NewClient(context.Background())
+	defer client.Close()
+
+	logDir := shared.LogDir(p.Name)
+	os.MkdirAll(logDir, 0755)
+
+	cmd := exec.Command(p.Command, append(append([]string{}, p.Args...), args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		shared.EnvServerURL+"="+client.BaseURL(),
+		"OPENCODE_URL="+client.BaseURL(),
+		shared.EnvLogDir+"="+logDir,
+	)
+
+	// Ctrl-C reaches the child through the terminal; stay alive to release the lease
+	// and pass a SIGTERM meant for us on to the child
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 127
+	}
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGTERM {
+				cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isExecutable reports whether path is a regular file (or symlink to one) with an exec bit
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
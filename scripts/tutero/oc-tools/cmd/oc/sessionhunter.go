@@ -0,0 +1,23 @@
+package main
+
+import (
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+var sessionHunter = &shared.Tool{
+	Name:    "session-hunter",
+	Summary: "Find past opencode sessions by what they changed",
+	Usage: `Usage: session-hunter [-s SESSION_ID] [-v] "prompt"
+   or: echo "prompt" | session-hunter [-s SESSION_ID]
+   or: session-hunter [-s SESSION_ID] <<EOF
+       prompt text here
+       EOF`,
+	Examples: `  session-hunter "Find session where UpdateLessonPlanForClass was replaced"
+  session-hunter "Which session modified worksheet_app_bar.dart?"
+  echo "Find all sessions that touched frontend/app/schools-app" | session-hunter
+  session-hunter -s ses_abc123 "search for more sessions with similar changes"`,
+	Timeout:  10 * time.Minute,
+	Isolated: true,
+}
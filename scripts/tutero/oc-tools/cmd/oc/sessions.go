@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+
+	"tutero/oc-tools/shared"
+)
+
+const sessionsUsage = `Usage: oc sessions [list] [--tool NAME] [--dir DIR] [--since 7d] [--limit N] [--json] [SEARCH]
+       oc sessions show SESSION_ID
+       oc sessions resume SESSION_ID [tool options] ["prompt"]
+       oc sessions export SESSION_ID [--format md|json|html] [--reasoning] [-o FILE]
+       oc sessions delete SESSION_ID...
+   or: oc-sessions ...
+
+Every session a tool creates is recorded in ~/.cache/scripts/oc-tools/sessions with its
+tool, agent, title, first prompt, directory, git branch and model.
+
+Commands:
+  list                        List sessions, most recently used first (default)
+  show                        Print the session's record and transcript
+  resume                      Continue the session in the tool that created it, in its directory
+  export                      Write the transcript as Markdown, JSON or HTML
+  delete                      Delete sessions on the server and from the registry
+
+List options:
+  --tool NAME                 Only sessions of this tool
+  --dir DIR                   Only sessions in DIR or below ("." = here)
+  --since AGE|DATE            Only sessions used in the last AGE (12h, 7d) or since DATE
+  --limit N                   Show at most N sessions (default 20, 0 = all)
+  --json                      Print JSON records instead of a table
+  SEARCH                      Only sessions whose title, first prompt or branch contain SEARCH
+`
+
+var sessionsSubcommands = []string{
+	"list\tList sessions",
+	"show\tPrint a session's record and transcript",
+	"resume\tContinue a session in its tool",
+	"export\tWrite a session's transcript as Markdown, JSON or HTML",
+	"delete\tDelete sessions",
+}
+
+// sessionsCommand implements `oc sessions`
+func sessionsCommand(args []string) int {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "list", "ls":
+		return sessionsList(args)
+	case "show":
+		return sessionsShow(args)
+	case "resume":
+		return sessionsResume(args)
+	case "export":
+		return sessionsExport(args)
+	case "delete", "rm":
+		return sessionsDelete(args)
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stderr, sessionsUsage)
+		return 0
+	}
+	// `oc sessions SEARCH` lists too
+	return sessionsList(append([]string{sub}, args...))
+}
+
+func sessionsList(args []string) int {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, sessionsUsage) }
+	toolName := fs.String("tool", "", "Only sessions of this tool")
+	dir := fs.String("dir", "", "Only sessions in this directory")
+	since := fs.String("since", "", "Only sessions used since")
+	limit := fs.Int("limit", 20, "Show at most N sessions")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	search := strings.ToLower(strings.Join(fs.Args(), " "))
+
+	var cutoff time.Time
+	if *since != "" {
+		var err error
+		if cutoff, err = parseSince(*since); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if *dir != "" {
+		abs, err := filepath.Abs(shared.ExpandHome(*dir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		*dir = abs
+	}
+
+	records, err := shared.ListSessionRecords()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var listed []*shared.SessionRecord
+	for _, rec := range records {
+		if *limit > 0 && len(listed) >= *limit {
+			break
+		}
+		if rec.Updated.Before(cutoff) || (*toolName != "" && rec.Tool != *toolName) {
+			continue
+		}
+		if *dir != "" && rec.WorkDir != *dir && !strings.HasPrefix(rec.WorkDir, *dir+string(filepath.Separator)) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(rec.Title+"\n"+rec.Summary+"\n"+rec.Branch), search) {
+			continue
+		}
+		listed = append(listed, rec)
+	}
+
+	if *asJSON {
+		if listed == nil {
+			listed = []*shared.SessionRecord{}
+		}
+		data, _ := json.MarshalIndent(listed, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+	if len(listed) == 0 {
+		fmt.Fprintln(os.Stderr, "No sessions found")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tTOOL\tUSED\tMODEL\tBRANCH\tSUMMARY")
+	for _, rec := range listed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", rec.ID, rec.Tool, rec.Updated.Local().Format("2006-01-02 15:04"),
+			orDash(rec.Model), orDash(rec.Branch), sessionLabel(rec))
+	}
+	w.Flush()
+	return 0
+}
+
+// sessionLabel prefers the first prompt; opencode's own titles are often just tool-timestamp
+func sessionLabel(rec *shared.SessionRecord) string {
+	if rec.Summary != "" {
+		return rec.Summary
+	}
+	return rec.Title
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func sessionsShow(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: oc sessions show SESSION_ID")
+		return 2
+	}
+	rec, code := loadRecord(args[0])
+	if rec == nil {
+		return code
+	}
+
+	fmt.Printf("Session:  %s\n", rec.ID)
+	fmt.Printf("Title:    %s\n", rec.Title)
+	fmt.Printf("Tool:     %s\n", rec.Tool)
+	if rec.Agent != "" {
+		fmt.Printf("Agent:    %s\n", rec.Agent)
+	}
+	if rec.System != "" {
+		fmt.Printf("System:   %d chars\n", len(rec.System))
+	}
+	fmt.Printf("Model:    %s\n", orDash(rec.Model))
+	fmt.Printf("Workdir:  %s\n", rec.WorkDir)
+	if rec.Branch != "" {
+		fmt.Printf("Branch:   %s\n", rec.Branch)
+	}
+	if rec.ForkedFrom != "" {
+		fork := &shared.ForkPoint{SessionID: rec.ForkedFrom, MessageID: rec.ForkedAt}
+		fmt.Printf("Forked:   from %s\n", fork)
+	}
+	fmt.Printf("Created:  %s\n", rec.Created.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Used:     %s (%d prompts)\n", rec.Updated.Local().Format("2006-01-02 15:04:05"), rec.Prompts)
+	if rec.LogPath != "" {
+		fmt.Printf("Log:      %s\n", rec.LogPath)
+	}
+
+	client, cancel := sessionClient(rec)
+	defer cancel()
+	defer client.Close()
+	messages, err := client.Session.Messages(context.Background(), rec.ID, opencode.SessionMessagesParams{
+		Directory: opencode.F(rec.WorkDir),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not fetch transcript: %v\n", err)
+		return 1
+	}
+	for _, message := range *messages {
+		printMessage(message)
+	}
+	return 0
+}
+
+// printMessage prints one message of a transcript: text in full, tool calls as one line
+func printMessage(message opencode.SessionMessagesResponse) {
+	var header string
+	switch info := message.Info.AsUnion().(type) {
+	case opencode.UserMessage:
+		header = "user · " + formatMillis(info.Time.Created)
+	case opencode.AssistantMessage:
+		header = fmt.Sprintf("assistant · %s/%s · %s", info.ProviderID, info.ModelID, formatMillis(info.Time.Created))
+	}
+	// The ID is what --fork SESSION:MESSAGE takes
+	header += " · " + message.Info.ID
+	fmt.Printf("\n── %s ──\n", header)
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case opencode.PartTypeText:
+			fmt.Println(part.Text)
+		case opencode.PartTypeTool:
+			line := "[tool " + part.Tool
+			if state, ok := part.State.(opencode.ToolPartState); ok {
+				if state.Title != "" {
+					line += ": " + state.Title
+				}
+				line += " (" + string(state.Status) + ")"
+			}
+			fmt.Println(line + "]")
+		case opencode.PartTypeFile:
+			fmt.Printf("[file %s]\n", part.Filename)
+		}
+	}
+}
+
+// formatMillis formats a message time (Unix milliseconds)
+func formatMillis(ms float64) string {
+	return time.UnixMilli(int64(ms)).Local().Format("2006-01-02 15:04:05")
+}
+
+func sessionsResume(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, `Usage: oc sessions resume SESSION_ID [tool options] ["prompt"]`)
+		return 2
+	}
+	rec, code := loadRecord(args[0])
+	if rec == nil {
+		return code
+	}
+	tool := findTool(rec.Tool)
+	if tool == nil {
+		fmt.Fprintf(os.Stderr, "Error: session %s was created by %q, which is not an oc tool\n", rec.ID, rec.Tool)
+		return 1
+	}
+
+	// Later flags win, so --workdir given here still overrides the recorded one
+	resume := []string{"-s", rec.ID, "--workdir", rec.WorkDir}
+	// oc-run sessions run whatever agent and system prompt they were created with, and
+	// the follow-up needs the same ones (other tools always use their own agent)
+	if tool == ocRun {
+		if rec.Agent != "" {
+			resume = append(resume, "--agent", rec.Agent)
+		}
+		if rec.System != "" && !hasFlag(args[1:], "system-file") {
+			resume = append(resume, "--system", rec.System)
+		}
+	}
+	return tool.Execute(append(resume, args[1:]...))
+}
+
+// hasFlag reports whether args set the flag name (as -name or --name, with or without =value)
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flagName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sessionsDelete(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: oc sessions delete SESSION_ID...")
+		return 2
+	}
+	status := 0
+	var records []*shared.SessionRecord
+	for _, id := range args {
+		rec, code := loadRecord(id)
+		if rec == nil {
+			status = code
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	for _, rec := range mainServerFirst(records) {
+		if err := deleteSession(rec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Deleted %s\n", rec.ID)
+	}
+	return status
+}
+
+// mainServerFirst orders records so sessions on the main server come before isolated
+// ones: connecting to the isolated server calls IsolateDataDir, which changes this
+// process's environment for good
+func mainServerFirst(records []*shared.SessionRecord) []*shared.SessionRecord {
+	sort.SliceStable(records, func(i, j int) bool {
+		return !records[i].Isolated && records[j].Isolated
+	})
+	return records
+}
+
+// deleteSession deletes the session on its server and drops its record
+// A session the server no longer has is only dropped from the registry
+func deleteSession(rec *shared.SessionRecord) error {
+	client, cancel := sessionClient(rec)
+	defer cancel()
+	defer client.Close()
+
+	if err := client.DeleteSession(rec.ID, rec.WorkDir); err != nil && !shared.IsNotFound(err) {
+		return err
+	}
+	return shared.RemoveSessionRecord(rec.ID)
+}
+
+// loadRecord reads a session's record, printing the error and returning the exit status
+// if there is none
+func loadRecord(id string) (*shared.SessionRecord, int) {
+	rec, err := shared.LoadSessionRecord(id)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Error: no session %s in the registry (see oc sessions list)\n", id)
+		return nil, 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, 1
+	}
+	return rec, 0
+}
+
+// sessionClient connects to the server the session lives on
+func sessionClient(rec *shared.SessionRecord) (*shared.Client, context.CancelFunc) {
+	if rec.Isolated {
+		shared.IsolateDataDir()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	return shared.NewClient(ctx), cancel
+}
+
+// completeSessions offers subcommands, then registered session IDs
+func completeSessions(rest []string, cur string) []string {
+	if len(rest) == 0 {
+		return sessionsSubcommands
+	}
+	switch rest[0] {
+	case "show", "resume", "export", "delete", "rm":
+		if rest[0] == "export" && len(rest) > 1 {
+			if rest[len(rest)-1] == "--format" {
+				return []string{"md", "json", "html"}
+			}
+			return []string{"--format", "--reasoning", "--output"}
+		}
+		if len(rest) > 1 && rest[0] != "delete" && rest[0] != "rm" {
+			return nil
+		}
+		records, _ := shared.ListSessionRecords()
+		var candidates []string
+		for _, rec := range records {
+			candidates = append(candidates, rec.ID+"\t"+rec.Tool+" "+sessionLabel(rec))
+		}
+		return candidates
+	}
+	if strings.HasPrefix(cur, "-") {
+		return []string{"--tool", "--dir", "--since", "--limit", "--json"}
+	}
+	return nil
+}
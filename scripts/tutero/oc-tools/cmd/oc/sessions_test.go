@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"tutero/oc-tools/shared"
+)
+
+func TestHasFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--system-file", "r.md", "go on"}, true},
+		{[]string{"-system-file=r.md"}, true},
+		{[]string{"--system", "terse"}, false},
+		{[]string{"--model", "a/b", "--system-file"}, true},
+		{[]string{"--", "--system-file"}, false},
+		{[]string{"system-file"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasFlag(tt.args, "system-file"); got != tt.want {
+			t.Errorf("hasFlag(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestMainServerFirst(t *testing.T) {
+	records := []*shared.SessionRecord{
+		{ID: "ses_iso1", Isolated: true},
+		{ID: "ses_main1"},
+		{ID: "ses_iso2", Isolated: true},
+		{ID: "ses_main2"},
+	}
+	var ids []string
+	for _, rec := range mainServerFirst(records) {
+		ids = append(ids, rec.ID)
+	}
+	if got, want := strings.Join(ids, " "), "ses_main1 ses_main2 ses_iso1 ses_iso2"; got != want {
+		t.Errorf("mainServerFirst() = %s, want %s", got, want)
+	}
+}
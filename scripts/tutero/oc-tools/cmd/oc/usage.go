@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+const usageUsage = `Usage: oc usage [--by tool,model,day,workdir] [--since 7d|2025-01-31] [--tool NAME] [--json]
+   or: oc-usage [options]
+
+Reports tokens, cost and time of past runs from the usage ledger every tool appends to
+(~/.cache/scripts/oc-tools/usage.jsonl).
+
+Options:
+  --by LIST                   Groupings to show: tool, model, day, workdir (default: all)
+  --since AGE|DATE            Only runs in the last AGE (30m, 12h, 7d) or since DATE
+  --tool NAME                 Only runs of this tool
+  --json                      Print JSON instead of tables
+  -h, --help                  Show this help message
+`
+
+// usageGroupings are the ways `oc usage` can total the ledger, in report order
+var usageGroupings = []string{"tool", "model", "day", "workdir"}
+
+// usageTotal sums the ledger entries sharing a key
+type usageTotal struct {
+	Key     string          `json:"key"`
+	Runs    int             `json:"runs"`
+	Partial int             `json:"partial,omitempty"`
+	Failed  int             `json:"failed,omitempty"` // Runs that failed or were stopped without output
+	Usage   shared.Usage    `json:"usage"`
+	Cost    float64         `json:"cost"`
+	Elapsed shared.Duration `json:"elapsed"`
+}
+
+func (t *usageTotal) add(entry shared.LedgerEntry) {
+	t.Runs++
+	if entry.Partial {
+		t.Partial++
+	} else if entry.Error != "" {
+		t.Failed++
+	}
+	t.Usage.Input += entry.Usage.Input
+	t.Usage.Output += entry.Usage.Output
+	t.Usage.Reasoning += entry.Usage.Reasoning
+	t.Usage.CacheRead += entry.Usage.CacheRead
+	t.Usage.CacheWrite += entry.Usage.CacheWrite
+	t.Cost += entry.Cost
+	t.Elapsed += entry.Elapsed
+}
+
+// usageCommand implements `oc usage`
+func usageCommand(args []string) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usageUsage) }
+	by := fs.String("by", strings.Join(usageGroupings, ","), "Groupings to show")
+	since := fs.String("since", "", "Only runs since")
+	toolName := fs.String("tool", "", "Only runs of this tool")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	groupings := strings.Split(*by, ",")
+	for _, grouping := range groupings {
+		if !slices.Contains(usageGroupings, grouping) {
+			fmt.Fprintf(os.Stderr, "Error: unknown grouping %q (want %s)\n", grouping, strings.Join(usageGroupings, ", "))
+			return 2
+		}
+	}
+	var cutoff time.Time
+	if *since != "" {
+		var err error
+		if cutoff, err = parseSince(*since); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	entries, err := shared.ReadLedger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	total, report := totalUsage(entries, groupings, cutoff, *toolName)
+	if *asJSON {
+		out := struct {
+			Total  usageTotal               `json:"total"`
+			Groups map[string][]*usageTotal `json:"groups"`
+		}{total, report}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+
+	if total.Runs == 0 {
+		fmt.Fprintf(os.Stderr, "No runs recorded in %s\n", shared.LedgerPath())
+		return 0
+	}
+	for _, grouping := range groupings {
+		printTotals(strings.ToUpper(grouping), report[grouping])
+		fmt.Println()
+	}
+	printTotals("", []*usageTotal{&total})
+	return 0
+}
+
+// totalUsage sums the entries since cutoff (of toolName, if set), overall and under
+// each grouping, with each grouping's totals sorted for the report
+func totalUsage(entries []shared.LedgerEntry, groupings []string, cutoff time.Time, toolName string) (usageTotal, map[string][]*usageTotal) {
+	total := usageTotal{Key: "total"}
+	totals := map[string]map[string]*usageTotal{}
+	for _, grouping := range groupings {
+		totals[grouping] = map[string]*usageTotal{}
+	}
+	for _, entry := range entries {
+		if entry.Time.Before(cutoff) || (toolName != "" && entry.Tool != toolName) {
+			continue
+		}
+		total.add(entry)
+		for _, grouping := range groupings {
+			key := usageKey(entry, grouping)
+			if totals[grouping][key] == nil {
+				totals[grouping][key] = &usageTotal{Key: key}
+			}
+			totals[grouping][key].add(entry)
+		}
+	}
+
+	report := map[string][]*usageTotal{}
+	for _, grouping := range groupings {
+		report[grouping] = sortTotals(totals[grouping], grouping)
+	}
+	return total, report
+}
+
+// usageKey returns what an entry is totalled under for a grouping
+func usageKey(entry shared.LedgerEntry, grouping string) string {
+	var key string
+	switch grouping {
+	case "tool":
+		key = entry.Tool
+	case "model":
+		key = entry.Model
+	case "day":
+		key = entry.Time.Local().Format("2006-01-02")
+	case "workdir":
+		key = entry.WorkDir
+	}
+	if key == "" {
+		return "(unknown)"
+	}
+	return key
+}
+
+// sortTotals orders days newest first and everything else by cost, most expensive first
+func sortTotals(totals map[string]*usageTotal, grouping string) []*usageTotal {
+	var sorted []*usageTotal
+	for _, t := range totals {
+		sorted = append(sorted, t)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if grouping == "day" {
+			return a.Key > b.Key
+		}
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.Runs != b.Runs {
+			return a.Runs > b.Runs
+		}
+		return a.Key < b.Key
+	})
+	return sorted
+}
+
+func printTotals(heading string, totals []*usageTotal) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tRUNS\tINPUT\tOUTPUT\tREASONING\tCACHE READ\tCACHE WRITE\tCOST\tTIME\n", heading)
+	for _, t := range totals {
+		runs := strconv.Itoa(t.Runs)
+		var notes []string
+		if t.Partial > 0 {
+			notes = append(notes, fmt.Sprintf("%d partial", t.Partial))
+		}
+		if t.Failed > 0 {
+			notes = append(notes, fmt.Sprintf("%d failed", t.Failed))
+		}
+		if len(notes) > 0 {
+			runs += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t$%.4f\t%v\n", t.Key, runs,
+			formatCount(t.Usage.Input), formatCount(t.Usage.Output), formatCount(t.Usage.Reasoning),
+			formatCount(t.Usage.CacheRead), formatCount(t.Usage.CacheWrite), t.Cost,
+			time.Duration(t.Elapsed).Round(100*time.Millisecond))
+	}
+	w.Flush()
+}
+
+// formatCount shows a token count with thousands separators
+func formatCount(n float64) string {
+	s := strconv.FormatFloat(n, 'f', 0, 64)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// parseSince reads --since: an age (30m, 12h, 7d) or a date (2025-01-31)
+func parseSince(s string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (want an age like 7d or 12h, or a date like 2025-01-31)", s)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"30m", now.Add(-30 * time.Minute), false},
+		{"2025-01-31", time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local), false},
+		{"d", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"2025-13-01", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSince(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if diff := got.Sub(tt.want); diff < -time.Second || diff > time.Second {
+			t.Errorf("parseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTotalUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	for _, entry := range []shared.LedgerEntry{
+		{Time: day1, Tool: "big-brain", Model: "anthropic/claude-opus-4-1", WorkDir: "/repo", Usage: shared.Usage{Input: 1000, Output: 100}, Cost: 0.5},
+		{Time: day1, Tool: "web-search", Model: "opencode/free-b", WorkDir: "/repo", Usage: shared.Usage{Input: 200}, Cost: 0},
+		{Time: day2, Tool: "big-brain", Model: "anthropic/claude-opus-4-1", WorkDir: "/other", Usage: shared.Usage{Input: 3000}, Cost: 1.5, Partial: true, Error: "timed out"},
+		{Time: day2, Tool: "big-brain", WorkDir: "/repo", Usage: shared.Usage{Input: 10}, Cost: 0.01, Error: "interrupted"},
+	} {
+		if err := shared.RecordUsage(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := shared.ReadLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys lists a grouping's totals as key:runs:partial:failed, in report order
+	keys := func(totals []*usageTotal) string {
+		var out []string
+		for _, t := range totals {
+			out = append(out, fmt.Sprintf("%s:%d:%d:%d", t.Key, t.Runs, t.Partial, t.Failed))
+		}
+		return strings.Join(out, " ")
+	}
+
+	total, report := totalUsage(entries, usageGroupings, time.Time{}, "")
+	if total.Runs != 4 || total.Partial != 1 || total.Failed != 1 || total.Usage.Input != 4210 || math.Abs(total.Cost-2.01) > 1e-9 {
+		t.Errorf("total = %+v", total)
+	}
+	want := map[string]string{
+		"tool":    "big-brain:3:1:1 web-search:1:0:0",
+		"model":   "anthropic/claude-opus-4-1:2:1:0 (unknown):1:0:1 opencode/free-b:1:0:0",
+		"day":     "2026-03-02:2:1:1 2026-03-01:2:0:0", // Newest first
+		"workdir": "/other:1:1:0 /repo:3:0:1",
+	}
+	for grouping, want := range want {
+		if got := keys(report[grouping]); got != want {
+			t.Errorf("by %s = %s, want %s", grouping, got, want)
+		}
+	}
+
+	total, report = totalUsage(entries, []string{"tool"}, day2, "big-brain")
+	if total.Runs != 2 || keys(report["tool"]) != "big-brain:2:1:1" || report["model"] != nil {
+		t.Errorf("since day 2, big-brain only: total %+v, report %v", total, report)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"tutero/oc-tools/shared"
+)
+
+// Fallback model when no free opencode models available (config: fallback_model)
+const defaultFallbackModel = "anthropic/claude-haiku-4-5"
+
+// webSearchGuard is set while web-search runs: opencode's web-search agent may call this
+// script (a run variable, so the server daemon doesn't pass it on to every agent)
+var webSearchGuard = shared.RunEnv("_WEB_SEARCH_RUNNING")
+
+var webSearch = &shared.Tool{
+	Name:    "web-search",
+	Summary: "Search the web with a free opencode model",
+	Usage: `Usage: web-search [-s SESSION_ID] [-v] "prompt"
+   or: echo "prompt" | web-search [-s SESSION_ID]`,
+	ModelHelp: "Use this model instead of the best free opencode model",
+	Examples: `  web-search "latest news on AI"
+  echo "what is the weather in NYC" | web-search
+  web-search -s ses_abc123 "find more details on that topic"`,
+	// Use /tmp to prevent loading any project AGENTS.md files
+	// Web-search only needs its own agent file at ~/.config/opencode/agent/web-search.md
+	WorkDir:       "/tmp",
+	Timeout:       10 * time.Minute,
+	FallbackModel: defaultFallbackModel,
+	ModelPolicy:   shared.FreeModelPolicy(),
+	Isolated:      true,
+	Prepare: func(inv *shared.Invocation) error {
+		// Prevent recursive invocation - opencode's web-search agent may call this script
+		if os.Getenv(webSearchGuard) == "1" {
+			return fmt.Errorf("web-search cannot be called recursively")
+		}
+		os.Setenv(webSearchGuard, "1")
+		return nil
+	},
+	Options: webSearchOptions,
+}
+
+// webSearchOptions keeps the web-search agent (instructions from ~/.config/opencode/agent/web-search.md)
+// with the model the runner picked: a configured one, else the best free opencode model
+// (ModelPolicy), else fallback_model
+func webSearchOptions(inv *shared.Invocation) (*shared.AgentOptions, error) {
+	if inv.Model == nil {
+		return nil, fmt.Errorf("no free opencode models available and no fallback_model configured")
+	}
+
+	opts := &shared.AgentOptions{
+		Model:      inv.Model,
+		Fallbacks:  inv.Fallbacks,
+		Tools:      webSearchTools(inv.Model),
+		ModelTools: webSearchTools,
+	}
+	inv.Log("Using model: %s (fallbacks: %v)", inv.Model, inv.Fallbacks)
+	if opts.Tools["websearch"] {
+		fmt.Fprintf(os.Stderr, "[web-search] Using model: %s\n", inv.Model)
+	} else {
+		fmt.Fprintf(os.Stderr, "[web-search] Using model: %s (websearch tool disabled)\n", inv.Model)
+	}
+	return opts, nil
+}
+
+// webSearchTools enables the websearch tool, which only works on the opencode provider
+// Fallbacks elsewhere answer from what the model knows
+func webSearchTools(model *shared.ModelConfig) map[string]bool {
+	return map[string]bool{"websearch": model.ProviderID == "opencode"}
+}
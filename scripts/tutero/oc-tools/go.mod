@@ -2,7 +2,10 @@ module tutero/oc-tools
 
 go 1.22
 
-require github.com/sst/opencode-sdk-go v0.19.0
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/sst/opencode-sdk-go v0.19.0
+)
 
 require (
 	github.com/tidwall/gjson v1.14.4 // indirect
@@ -0,0 +1,139 @@
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// MaxAttachmentSize caps each attached file; providers reject far smaller images anyway
+const MaxAttachmentSize = 20 << 20
+
+// Bytes sniffed for the MIME type and binary detection
+const sniffLen = 8192
+
+// Attachment is a file sent alongside the prompt as a file part
+type Attachment struct {
+	Path string // Absolute path
+	Mime string // text/plain for any text file; the server inlines those
+	Size int64
+}
+
+// LoadAttachment checks that path (relative to baseDir, ~ expanded) can be attached:
+// a regular file within MaxAttachmentSize that is text, an image or a PDF
+func LoadAttachment(path, baseDir string) (*Attachment, error) {
+	path = ExpandHome(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("cannot attach %s: not a regular file", path)
+	}
+	if info.Size() > MaxAttachmentSize {
+		return nil, fmt.Errorf("cannot attach %s: %d MB is over the %d MB limit", path, info.Size()>>20, MaxAttachmentSize>>20)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	defer f.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	head = head[:n]
+
+	mime, err := attachmentMime(head)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	return &Attachment{Path: path, Mime: mime, Size: info.Size()}, nil
+}
+
+// attachmentMime returns the MIME type to send a file as, judging by its first bytes
+// Images and PDFs go to the model as they are; anything else must be text, as other
+// binaries (archives, executables, ...) would only reach the model as noise.
+func attachmentMime(head []byte) (string, error) {
+	detected := http.DetectContentType(head)
+	if strings.HasPrefix(detected, "image/") || detected == "application/pdf" {
+		return detected, nil
+	}
+	if looksBinary(head) {
+		return "", fmt.Errorf("binary file (%s); only text, images and PDFs can be attached", detected)
+	}
+	return "text/plain", nil
+}
+
+// looksBinary reports whether data has NUL bytes or isn't UTF-8 (allowing a rune cut
+// off at the end of the sniffed bytes)
+func looksBinary(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			return len(data) >= utf8.UTFMax
+		}
+		data = data[size:]
+	}
+	return false
+}
+
+// part returns the attachment as a prompt part; the server reads the file itself
+func (a *Attachment) part() opencode.FilePartInputParam {
+	return opencode.FilePartInputParam{
+		Type:     opencode.F(opencode.FilePartInputTypeFile),
+		Mime:     opencode.F(a.Mime),
+		URL:      opencode.F((&url.URL{Scheme: "file", Path: a.Path}).String()),
+		Filename: opencode.F(filepath.Base(a.Path)),
+	}
+}
+
+func (a *Attachment) String() string {
+	return fmt.Sprintf("%s (%s, %d bytes)", a.Path, a.Mime, a.Size)
+}
+
+// attachRef matches @path at the start of the prompt or after whitespace or an
+// opening bracket or quote
+var attachRef = regexp.MustCompile("(^|[\\s(\\[\"'`])@([^\\s)\\]\"'`,;]+)")
+
+// ExpandAttachments finds @path references in prompt and returns the prompt with the
+// @ dropped (so the text still names the file) plus the paths to attach
+// Only references to existing files (relative to baseDir, ~ expanded) count, so @mentions
+// and emails are left alone. Trailing sentence punctuation isn't part of the path.
+func ExpandAttachments(prompt, baseDir string) (string, []string) {
+	var paths []string
+	expanded := attachRef.ReplaceAllStringFunc(prompt, func(match string) string {
+		groups := attachRef.FindStringSubmatch(match)
+		lead, ref := groups[1], strings.TrimRight(groups[2], ".:!?")
+		trailing := strings.TrimPrefix(groups[2], ref)
+
+		path := ExpandHome(ref)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return match
+		}
+		paths = append(paths, ref)
+		return lead + ref + trailing
+	})
+	return expanded, paths
+}
@@ -0,0 +1,117 @@
+package shared
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandAttachments(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "notes.md", "sub/util.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("text\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := filepath.Join(dir, "notes.md")
+
+	tests := []struct {
+		prompt string
+		want   string
+		paths  []string
+	}{
+		{"review @main.go", "review main.go", []string{"main.go"}},
+		{"@main.go and @sub/util.go", "main.go and sub/util.go", []string{"main.go", "sub/util.go"}},
+		{"what does @main.go do?", "what does main.go do?", []string{"main.go"}},
+		{"see @notes.md.", "see notes.md.", []string{"notes.md"}},
+		{"compare (@main.go) with '@notes.md'", "compare (main.go) with 'notes.md'", []string{"main.go", "notes.md"}},
+		{"read @" + abs, "read " + abs, []string{abs}},
+		{"ask @alice about it", "ask @alice about it", nil}, // Not a file
+		{"mail bob@main.go", "mail bob@main.go", nil},       // Not after whitespace
+		{"look in @sub", "look in @sub", nil},               // A directory
+		{"@missing.go, @main.go", "@missing.go, main.go", []string{"main.go"}},
+		{"no references", "no references", nil},
+	}
+	for _, tt := range tests {
+		got, paths := ExpandAttachments(tt.prompt, dir)
+		if got != tt.want || !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("ExpandAttachments(%q) = %q, %q; want %q, %q", tt.prompt, got, paths, tt.want, tt.paths)
+		}
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	euro := []byte("price: €") // € is three bytes
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"ASCII", []byte("package main\n"), false},
+		{"UTF-8", []byte("naïve café ✓"), false},
+		{"rune cut off by the sniff", euro[:len(euro)-1], false},
+		{"NUL byte", []byte("ELF\x00\x01"), true},
+		{"invalid UTF-8", []byte("caf\xe9 au lait"), true},
+		{"Latin-1 at the end", []byte("a long enough caf\xe9"), false}, // Can't tell from a cut-off rune
+	}
+	for _, tt := range tests {
+		if got := looksBinary(tt.data); got != tt.want {
+			t.Errorf("looksBinary(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAttachment(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"main.go":   []byte("package main\n"),
+		"image.png": append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...),
+		"doc.pdf":   []byte("%PDF-1.7\n"),
+		"app.bin":   {0x7f, 'E', 'L', 'F', 0, 0, 0, 0},
+		"big.txt":   bytes.Repeat([]byte("a"), MaxAttachmentSize+1),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		mime string
+		err  string
+	}{
+		{"main.go", "text/plain", ""},
+		{"image.png", "image/png", ""},
+		{"doc.pdf", "application/pdf", ""},
+		{filepath.Join(dir, "main.go"), "text/plain", ""},
+		{"app.bin", "", "binary file"},
+		{"big.txt", "", "over the 20 MB limit"},
+		{"missing.txt", "", "no such file"},
+		{".", "", "not a regular file"},
+	}
+	for _, tt := range tests {
+		a, err := LoadAttachment(tt.path, dir)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LoadAttachment(%s) error = %v, want %q", tt.path, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LoadAttachment(%s): %v", tt.path, err)
+			continue
+		}
+		if a.Mime != tt.mime || !filepath.IsAbs(a.Path) {
+			t.Errorf("LoadAttachment(%s) = %s, want %s at an absolute path", tt.path, a, tt.mime)
+		}
+	}
+}
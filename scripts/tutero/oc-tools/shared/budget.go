@@ -0,0 +1,131 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Budget caps what a single run may spend; zero fields are unlimited
+// Tools may declare one; [tools.<name>.budget] in the config replaces it:
+//
+//	[tools.db-oracle.budget]
+//	max_cost          = 5       # USD
+//	max_output_tokens = 50000   # output + reasoning
+//	max_tool_calls    = 200
+//	max_wall_time     = "20m"
+type Budget struct {
+	MaxCost         float64  `toml:"max_cost" json:"max_cost"`                   // USD, as reported by the provider
+	MaxOutputTokens float64  `toml:"max_output_tokens" json:"max_output_tokens"` // Output plus reasoning tokens
+	MaxToolCalls    int      `toml:"max_tool_calls" json:"max_tool_calls"`
+	MaxWallTime     Duration `toml:"max_wall_time" json:"max_wall_time"`
+}
+
+// IsZero reports whether the budget limits nothing
+func (b *Budget) IsZero() bool {
+	return b == nil || (b.MaxCost == 0 && b.MaxOutputTokens == 0 && b.MaxToolCalls == 0 && b.MaxWallTime == 0)
+}
+
+// String summarizes the budget for logs
+func (b *Budget) String() string {
+	if b.IsZero() {
+		return "unlimited"
+	}
+	var parts []string
+	if b.MaxCost > 0 {
+		parts = append(parts, fmt.Sprintf("cost=$%g", b.MaxCost))
+	}
+	if b.MaxOutputTokens > 0 {
+		parts = append(parts, fmt.Sprintf("output_tokens=%g", b.MaxOutputTokens))
+	}
+	if b.MaxToolCalls > 0 {
+		parts = append(parts, fmt.Sprintf("tool_calls=%d", b.MaxToolCalls))
+	}
+	if b.MaxWallTime > 0 {
+		parts = append(parts, "wall_time="+b.MaxWallTime.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// BudgetExceededError is returned when a run went over one of its budget limits
+// The session was aborted on the server; what the agent wrote so far comes back as a PartialError
+type BudgetExceededError struct {
+	Limit string // cost, output tokens, tool calls or wall time
+	Used  string
+	Max   string
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s reached %s (limit %s)", e.Limit, e.Used, e.Max)
+}
+
+// check returns the first limit the run has gone over, or nil
+func (b *Budget) check(stats *runStats, elapsed time.Duration) *BudgetExceededError {
+	cost, output, toolCalls := stats.spent()
+	switch {
+	case b.MaxCost > 0 && cost > b.MaxCost:
+		return &BudgetExceededError{Limit: "cost", Used: fmt.Sprintf("$%.4f", cost), Max: fmt.Sprintf("$%g", b.MaxCost)}
+	case b.MaxOutputTokens > 0 && output > b.MaxOutputTokens:
+		return &BudgetExceededError{Limit: "output tokens", Used: fmt.Sprintf("%g", output), Max: fmt.Sprintf("%g", b.MaxOutputTokens)}
+	case b.MaxToolCalls > 0 && toolCalls > b.MaxToolCalls:
+		return &BudgetExceededError{Limit: "tool calls", Used: fmt.Sprint(toolCalls), Max: fmt.Sprint(b.MaxToolCalls)}
+	case b.MaxWallTime > 0 && elapsed >= time.Duration(b.MaxWallTime):
+		return &BudgetExceededError{Limit: "wall time", Used: elapsed.Round(100 * time.Millisecond).String(), Max: b.MaxWallTime.String()}
+	}
+	return nil
+}
+
+// enforceBudget checks the budget every time the event stream updates stats, and once the
+// wall time runs out. On a breach it aborts the session on the server and cancels the
+// prompt. stop must be called when the prompt returns; it returns the breach, if any.
+// startTime is when the run began, so retries and fallback models share one budget.
+func (c *Client) enforceBudget(budget *Budget, sessionID, workDir string, stats *runStats, startTime time.Time, cancelPrompt context.CancelFunc) (stop func() error) {
+	if budget.IsZero() {
+		return func() error { return nil }
+	}
+
+	var exceeded *BudgetExceededError
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	var wallTime <-chan time.Time
+	var timer *time.Timer
+	if budget.MaxWallTime > 0 {
+		timer = time.NewTimer(time.Duration(budget.MaxWallTime) - time.Since(startTime))
+		wallTime = timer.C
+	}
+
+	go func() {
+		defer close(finished)
+		if timer != nil {
+			defer timer.Stop()
+		}
+		for {
+			select {
+			case <-stats.changed:
+			case <-wallTime:
+			case <-done:
+				return
+			}
+			if exceeded = budget.check(stats, time.Since(startTime)); exceeded != nil {
+				c.log("Budget (%s) exceeded: %v", budget, exceeded)
+				fmt.Fprintf(os.Stderr, "\n[%v, aborting session %s]\n", exceeded, sessionID)
+				c.AbortSession(sessionID, workDir)
+				cancelPrompt()
+				return
+			}
+		}
+	}()
+
+	// exceeded is only written by the watcher, which has exited once finished is closed
+	return func() error {
+		close(done)
+		<-finished
+		if exceeded == nil {
+			return nil
+		}
+		return exceeded
+	}
+}
@@ -0,0 +1,159 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+// spend records an assistant message and n tool calls in stats
+func spend(stats *runStats, id string, cost, output, reasoning float64, toolCalls int) {
+	stats.observeMessage(opencode.AssistantMessage{
+		ID:     id,
+		Cost:   cost,
+		Tokens: opencode.AssistantMessageTokens{Output: output, Reasoning: reasoning},
+	})
+	for i := 0; i < toolCalls; i++ {
+		stats.observePart(opencode.Part{ID: fmt.Sprintf("prt_%s_%d", id, i), MessageID: id, Type: opencode.PartTypeTool, Tool: "read"})
+	}
+}
+
+func TestBudgetCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		budget    Budget
+		cost      float64
+		output    float64
+		reasoning float64
+		toolCalls int
+		elapsed   time.Duration
+		want      string // Limit exceeded ("" = none)
+	}{
+		{"zero budget", Budget{}, 100, 1e6, 1e6, 1000, 24 * time.Hour, ""},
+		{"under every limit", Budget{MaxCost: 1, MaxOutputTokens: 1000, MaxToolCalls: 10, MaxWallTime: Duration(time.Minute)}, 0.5, 500, 100, 5, 30 * time.Second, ""},
+		{"cost", Budget{MaxCost: 1}, 1.01, 0, 0, 0, 0, "cost"},
+		{"cost at the limit", Budget{MaxCost: 1}, 1, 0, 0, 0, 0, ""},
+		{"output tokens", Budget{MaxOutputTokens: 1000}, 0, 1001, 0, 0, 0, "output tokens"},
+		{"reasoning counts as output", Budget{MaxOutputTokens: 1000}, 0, 600, 500, 0, 0, "output tokens"},
+		{"tool calls", Budget{MaxToolCalls: 3}, 0, 0, 0, 4, 0, "tool calls"},
+		{"tool calls at the limit", Budget{MaxToolCalls: 3}, 0, 0, 0, 3, 0, ""},
+		{"wall time", Budget{MaxWallTime: Duration(time.Minute)}, 0, 0, 0, 0, time.Minute, "wall time"},
+		{"wall time left", Budget{MaxWallTime: Duration(time.Minute)}, 0, 0, 0, 0, 59 * time.Second, ""},
+		{"cost is checked first", Budget{MaxCost: 1, MaxToolCalls: 1}, 2, 0, 0, 5, 0, "cost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := newRunStats()
+			spend(stats, "msg_a", tt.cost, tt.output, tt.reasoning, tt.toolCalls)
+
+			exceeded := tt.budget.check(stats, tt.elapsed)
+			switch {
+			case tt.want == "" && exceeded != nil:
+				t.Errorf("check() = %v, want within budget", exceeded)
+			case tt.want != "" && (exceeded == nil || exceeded.Limit != tt.want):
+				t.Errorf("check() = %v, want %s exceeded", exceeded, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetIsZero(t *testing.T) {
+	var none *Budget
+	if !none.IsZero() || !(&Budget{}).IsZero() || (&Budget{MaxToolCalls: 1}).IsZero() {
+		t.Error("IsZero() wrong for nil, empty or set budgets")
+	}
+	if got := none.String(); got != "unlimited" {
+		t.Errorf("String() = %q, want unlimited", got)
+	}
+}
+
+// budgetClient returns a client whose server counts session aborts
+func budgetClient(t *testing.T, aborts *atomic.Int32) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/abort") {
+			aborts.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "true")
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{
+		Client: opencode.NewClient(option.WithBaseURL(srv.URL), option.WithMaxRetries(0)),
+		ctx:    context.Background(),
+	}
+}
+
+func TestEnforceBudget(t *testing.T) {
+	var aborts atomic.Int32
+	c := budgetClient(t, &aborts)
+
+	// Nothing to enforce
+	stop := c.enforceBudget(nil, "ses_1", "/tmp", newRunStats(), time.Now(), func() {})
+	if err := stop(); err != nil {
+		t.Errorf("zero budget: stop() = %v", err)
+	}
+
+	// Going over as the event stream reports usage aborts the session and the prompt
+	stats := newRunStats()
+	ctx, cancelPrompt := context.WithCancel(context.Background())
+	stop = c.enforceBudget(&Budget{MaxCost: 0.2}, "ses_1", "/tmp", stats, time.Now(), cancelPrompt)
+	spend(stats, "msg_a", 0.1, 0, 0, 0)
+	spend(stats, "msg_b", 0.15, 0, 0, 0)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("prompt not cancelled over budget")
+	}
+	var exceeded *BudgetExceededError
+	if err := stop(); !errors.As(err, &exceeded) || exceeded.Limit != "cost" || aborts.Load() != 1 {
+		t.Errorf("stop() = %v with %d aborts, want cost exceeded and 1 abort", err, aborts.Load())
+	}
+}
+
+func TestBudgetSharedAcrossRetries(t *testing.T) {
+	var aborts atomic.Int32
+	c := budgetClient(t, &aborts)
+	budget := &Budget{MaxCost: 0.2, MaxWallTime: Duration(300 * time.Millisecond)}
+
+	// The first attempt spends most of the budget, then fails and is retried
+	stats := newRunStats()
+	startTime := time.Now()
+	stop := c.enforceBudget(budget, "ses_1", "/tmp", stats, startTime, func() {})
+	spend(stats, "msg_a", 0.15, 0, 0, 0)
+	if err := stop(); err != nil {
+		t.Fatalf("first attempt: stop() = %v, want within budget", err)
+	}
+
+	// The retry shares the stats: its own spend alone is within budget, together they're not
+	ctx, cancelPrompt := context.WithCancel(context.Background())
+	stop = c.enforceBudget(budget, "ses_1", "/tmp", stats, startTime, cancelPrompt)
+	spend(stats, "msg_b", 0.1, 0, 0, 0)
+	<-ctx.Done()
+	var exceeded *BudgetExceededError
+	if err := stop(); !errors.As(err, &exceeded) || exceeded.Limit != "cost" {
+		t.Errorf("retry: stop() = %v, want cost exceeded", err)
+	}
+
+	// And the start time: a later attempt only gets what is left of the wall time
+	time.Sleep(time.Until(startTime.Add(300 * time.Millisecond)))
+	attempt := time.Now()
+	ctx, cancelPrompt = context.WithCancel(context.Background())
+	stop = c.enforceBudget(&Budget{MaxWallTime: budget.MaxWallTime}, "ses_1", "/tmp", newRunStats(), startTime, cancelPrompt)
+	<-ctx.Done()
+	if err := stop(); !errors.As(err, &exceeded) || exceeded.Limit != "wall time" {
+		t.Errorf("late attempt: stop() = %v, want wall time exceeded", err)
+	}
+	if waited := time.Since(attempt); waited > 200*time.Millisecond {
+		t.Errorf("late attempt ran %v, want it stopped at once", waited)
+	}
+}
@@ -0,0 +1,32 @@
+package shared
+
+// Throwaway sessions (AgentOptions.AutoCleanup) are deleted on every way out of a run:
+// after the answer, after an error or timeout, on Ctrl-C, and from Close as a last resort
+// for callers that bail out between creating a session and prompting it.
+
+// deleteLater marks a session for deletion by cleanupSessions
+func (c *Client) deleteLater(sessionID, workDir string) {
+	c.cleanupMu.Lock()
+	defer c.cleanupMu.Unlock()
+	if c.throwaway == nil {
+		c.throwaway = map[string]string{}
+	}
+	c.throwaway[sessionID] = workDir
+}
+
+// cleanupSessions deletes the sessions marked by deleteLater. A session whose prompt
+// didn't finish (unfinished) may still be generating, so it is aborted first.
+// Safe to call more than once: each session is only deleted by the first call.
+func (c *Client) cleanupSessions(unfinished bool) {
+	c.cleanupMu.Lock()
+	sessions := c.throwaway
+	c.throwaway = nil
+	c.cleanupMu.Unlock()
+
+	for sessionID, workDir := range sessions {
+		if unfinished {
+			c.AbortSession(sessionID, workDir)
+		}
+		c.DeleteSession(sessionID, workDir)
+	}
+}
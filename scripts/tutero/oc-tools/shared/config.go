@@ -0,0 +1,414 @@
+package shared
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds user defaults loaded from ~/.config/oc-tools/config.toml (or config.json)
+// Precedence, lowest first: built-in defaults < config file < OC_TOOLS_* env vars < command flags
+//
+//	hostname      = "localhost"
+//	port          = 4096
+//	isolated_port = 4097
+//	timeout       = "10m"      # every tool, over its built-in timeout ([tools.X] overrides)
+//	idle_timeout  = "15m"      # keep-warm daemon
+//	max_attempts  = 3          # tries per request on transient errors (rate limits, server restarts)
+//	retry_backoff = "2s"       # first retry delay, doubled each retry (with jitter)
+//	model_cache_ttl = "1h"     # how long the server's model list is reused ("0" = always fetch)
+//	auto_gc       = true       # oc gc in the background on tool start, at most once a day
+//
+//	[gc]
+//	max_age     = "30d"        # logs and sessions unused for longer are removed ("0" = keep)
+//	max_size_mb = 200          # per tool log dir, oldest logs first
+//
+//	[tools.db-oracle]
+//	timeout = "45m"
+//	workdir = "~/Coding/metarepo"
+//	model   = "anthropic/claude-opus-4-1"
+//
+//	[tools.web-search]
+//	fallback_model = "anthropic/claude-haiku-4-5"
+//
+//	[tools.big-brain]
+//	models = ["anthropic/claude-opus-4-1", "openai/gpt-5"] # tried in order on provider errors and rate limits
+//
+//	[tools.db-oracle.budget]   # the run is aborted with partial output past any limit
+//	max_cost       = 5         # USD
+//	max_tool_calls = 200
+//
+//	[tools.db-oracle.gc]
+//	max_age = "90d"
+//
+// Top-level keys must come before the first [table] header: TOML puts any key after it
+// into that table
+type Config struct {
+	Hostname      string                `toml:"hostname" json:"hostname"`
+	Port          int                   `toml:"port" json:"port"`
+	IsolatedPort  int                   `toml:"isolated_port" json:"isolated_port"`
+	Timeout       Duration              `toml:"timeout" json:"timeout"`
+	IdleTimeout   *Duration             `toml:"idle_timeout" json:"idle_timeout"` // Pointer so "0" can be told apart from unset
+	MaxAttempts   int                   `toml:"max_attempts" json:"max_attempts"`
+	RetryBackoff  Duration              `toml:"retry_backoff" json:"retry_backoff"`
+	ModelCacheTTL *Duration             `toml:"model_cache_ttl" json:"model_cache_ttl"` // Pointer so "0" can be told apart from unset
+	GC            GCPolicy              `toml:"gc" json:"gc"`                           // Retention for oc gc ([tools.<name>.gc] overrides)
+	AutoGC        bool                  `toml:"auto_gc" json:"auto_gc"`                 // Run oc gc in the background on tool start, at most daily
+	Tools         map[string]ToolConfig `toml:"tools" json:"tools"`
+
+	path string // File the config was loaded from (empty if none)
+}
+
+// ToolConfig holds per-tool settings; zero values mean "use the tool's built-in default"
+type ToolConfig struct {
+	Timeout       Duration     `toml:"timeout" json:"timeout"`
+	Model         string       `toml:"model" json:"model"`                   // provider/model override
+	FallbackModel string       `toml:"fallback_model" json:"fallback_model"` // Used when the preferred model is unavailable
+	Models        []string     `toml:"models" json:"models"`                 // Model chain, tried in order after Model
+	WorkDir       string       `toml:"workdir" json:"workdir"`               // ~ is expanded
+	MaxAttempts   int          `toml:"max_attempts" json:"max_attempts"`     // Tries per request on transient errors
+	RetryBackoff  Duration     `toml:"retry_backoff" json:"retry_backoff"`   // First retry delay
+	Policy        *ModelPolicy `toml:"policy" json:"policy"`                 // Picks the model when none is set (replaces the tool's own)
+	Budget        *Budget      `toml:"budget" json:"budget"`                 // Caps each run (replaces the tool's own)
+	GC            *GCPolicy    `toml:"gc" json:"gc"`                         // Retention for the tool's logs and sessions (fields set replace [gc])
+}
+
+// Duration is a time.Duration that reads "10m"-style strings from config files and flags
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Set implements flag.Value
+func (d *Duration) Set(s string) error {
+	if s == "0" {
+		*d = 0
+		return nil
+	}
+	// Retention periods read better in days
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			*d = Duration(time.Duration(n) * 24 * time.Hour)
+			return nil
+		}
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q (use e.g. 90s, 15m, 1h, 30d)", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+var (
+	loadedConfig *Config
+	configOnce   sync.Once
+)
+
+// ConfigDir returns $XDG_CONFIG_HOME/oc-tools (default ~/.config/oc-tools)
+func ConfigDir() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		base = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(base, "oc-tools")
+}
+
+// ConfigPath returns the config file in use: $OC_TOOLS_CONFIG, else config.toml or
+// config.json in ConfigDir
+func ConfigPath() string {
+	if p := os.Getenv("OC_TOOLS_CONFIG"); p != "" {
+		return p
+	}
+	dir := ConfigDir()
+	for _, name := range []string{"config.toml", "config.json"} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(dir, "config.toml")
+}
+
+// LoadConfig reads the user config once per process and applies env overrides
+// A missing file is fine; a broken one is reported on stderr and ignored
+func LoadConfig() *Config {
+	configOnce.Do(func() {
+		cfg := &Config{}
+		path := ConfigPath()
+		if err := cfg.load(path); err != nil {
+			fmt.Fprintf(os.Stderr, "[oc-tools] Warning: ignoring config %s: %v\n", path, err)
+			cfg = &Config{}
+		}
+		cfg.applyDefaults()
+		cfg.applyEnv()
+		loadedConfig = cfg
+	})
+	return loadedConfig
+}
+
+func (c *Config) load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, c)
+	} else {
+		_, err = toml.Decode(string(data), c)
+	}
+	if err != nil {
+		return err
+	}
+	c.path = path
+	return nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Hostname == "" {
+		c.Hostname = DefaultHostname
+	}
+	if c.Port == 0 {
+		c.Port = DefaultPort
+	}
+	if c.IsolatedPort == 0 {
+		c.IsolatedPort = IsolatedPort
+	}
+	if c.IdleTimeout == nil {
+		idle := Duration(DefaultIdleTimeout)
+		c.IdleTimeout = &idle
+	}
+	if c.ModelCacheTTL == nil {
+		ttl := Duration(DefaultModelCacheTTL)
+		c.ModelCacheTTL = &ttl
+	}
+	if c.GC.MaxAge == nil {
+		age := Duration(DefaultGCMaxAge)
+		c.GC.MaxAge = &age
+	}
+}
+
+// applyEnv layers OC_TOOLS_HOSTNAME, OC_TOOLS_PORT, OC_TOOLS_ISOLATED_PORT, OC_TOOLS_TIMEOUT,
+// OC_TOOLS_IDLE_TIMEOUT, OC_TOOLS_MAX_ATTEMPTS, OC_TOOLS_RETRY_BACKOFF,
+// OC_TOOLS_MODEL_CACHE_TTL and OC_TOOLS_GC_MAX_AGE over the file values
+func (c *Config) applyEnv() {
+	if v := os.Getenv("OC_TOOLS_HOSTNAME"); v != "" {
+		c.Hostname = v
+	}
+	envInt("OC_TOOLS_PORT", &c.Port)
+	envInt("OC_TOOLS_ISOLATED_PORT", &c.IsolatedPort)
+	envDuration("OC_TOOLS_TIMEOUT", &c.Timeout)
+	envDuration("OC_TOOLS_IDLE_TIMEOUT", c.IdleTimeout)
+	envInt("OC_TOOLS_MAX_ATTEMPTS", &c.MaxAttempts)
+	envDuration("OC_TOOLS_RETRY_BACKOFF", &c.RetryBackoff)
+	envDuration("OC_TOOLS_MODEL_CACHE_TTL", c.ModelCacheTTL)
+	envDuration("OC_TOOLS_GC_MAX_AGE", c.GC.MaxAge)
+}
+
+// Path returns the file the config was loaded from, or "" if defaults are in use
+func (c *Config) Path() string {
+	return c.path
+}
+
+// ServerPort returns the port for the shared server (isolated tools get their own)
+func (c *Config) ServerPort(isolated bool) int {
+	if isolated {
+		return c.IsolatedPort
+	}
+	return c.Port
+}
+
+// ServerURL returns the base URL for a server on port
+func (c *Config) ServerURL(port int) string {
+	return fmt.Sprintf("http://%s:%d", c.Hostname, port)
+}
+
+// Tool resolves the effective settings for a tool, starting from its built-in defaults:
+// defaults < global timeout/retry < [tools.<name>] < OC_TOOLS_<NAME>_{TIMEOUT,MODEL,FALLBACK_MODEL,MODELS,WORKDIR,MAX_ATTEMPTS,RETRY_BACKOFF}
+// (MODELS is comma-separated). A tool's built-in timeout is only its default: the
+// global timeout replaces it, and [tools.<name>] timeout replaces that.
+// Call RegisterFlags on the result to let command-line flags override it last.
+func (c *Config) Tool(name string, defaults ToolConfig) ToolConfig {
+	tc := defaults
+	if c.Timeout != 0 {
+		tc.Timeout = c.Timeout
+	}
+	if c.MaxAttempts != 0 {
+		tc.MaxAttempts = c.MaxAttempts
+	}
+	if c.RetryBackoff != 0 {
+		tc.RetryBackoff = c.RetryBackoff
+	}
+
+	if section, ok := c.Tools[name]; ok {
+		if section.Timeout != 0 {
+			tc.Timeout = section.Timeout
+		}
+		if section.Model != "" {
+			tc.Model = section.Model
+		}
+		if section.FallbackModel != "" {
+			tc.FallbackModel = section.FallbackModel
+		}
+		if len(section.Models) > 0 {
+			tc.Models = section.Models
+		}
+		if section.Policy != nil {
+			tc.Policy = section.Policy
+		}
+		if section.Budget != nil {
+			tc.Budget = section.Budget
+		}
+		if section.WorkDir != "" {
+			tc.WorkDir = section.WorkDir
+		}
+		if section.MaxAttempts != 0 {
+			tc.MaxAttempts = section.MaxAttempts
+		}
+		if section.RetryBackoff != 0 {
+			tc.RetryBackoff = section.RetryBackoff
+		}
+	}
+
+	prefix := "OC_TOOLS_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	envDuration(prefix+"TIMEOUT", &tc.Timeout)
+	envString(prefix+"MODEL", &tc.Model)
+	envString(prefix+"FALLBACK_MODEL", &tc.FallbackModel)
+	if v := os.Getenv(prefix + "MODELS"); v != "" {
+		tc.Models = strings.Split(v, ",")
+	}
+	envString(prefix+"WORKDIR", &tc.WorkDir)
+	envInt(prefix+"MAX_ATTEMPTS", &tc.MaxAttempts)
+	envDuration(prefix+"RETRY_BACKOFF", &tc.RetryBackoff)
+
+	if tc.Timeout == 0 {
+		tc.Timeout = Duration(DefaultTimeout)
+	}
+	if tc.MaxAttempts == 0 {
+		tc.MaxAttempts = DefaultMaxAttempts
+	}
+	if tc.RetryBackoff == 0 {
+		tc.RetryBackoff = Duration(DefaultRetryBackoff)
+	}
+	return tc
+}
+
+// RegisterFlags binds --timeout, --model and --workdir overrides onto fs
+// Current values become the flag defaults, so flags win over config and env
+func (t *ToolConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(&t.Timeout, "timeout", "Overall timeout (e.g. 15m, 0 = none)")
+	fs.StringVar(&t.Model, "model", t.Model, "Model override as provider/model")
+	fs.StringVar(&t.WorkDir, "workdir", t.WorkDir, "Directory the agent runs in")
+}
+
+// ModelConfig parses Model into a ModelConfig (nil if unset)
+func (t ToolConfig) ModelConfig() (*ModelConfig, error) {
+	return ParseModel(t.Model)
+}
+
+// Explicit reports whether a model or model chain is set, so no policy picks one
+func (t ToolConfig) Explicit() bool {
+	return t.Model != "" || len(t.Models) > 0
+}
+
+// ModelChain parses the models to try in order: Model, then Models, then FallbackModel,
+// without duplicates. Empty if none is configured (the agent's own model is used).
+func (t ToolConfig) ModelChain() ([]*ModelConfig, error) {
+	var chain []*ModelConfig
+	seen := map[string]bool{}
+	for _, s := range append(append([]string{t.Model}, t.Models...), t.FallbackModel) {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		model, err := ParseModel(s)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, model)
+	}
+	return chain, nil
+}
+
+// RetryPolicy returns the retry settings as a RetryPolicy
+func (t ToolConfig) RetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: t.MaxAttempts, Backoff: time.Duration(t.RetryBackoff)}
+}
+
+// ResolveWorkDir returns WorkDir with ~ expanded, or fallback if unset
+func (t ToolConfig) ResolveWorkDir(fallback string) string {
+	if t.WorkDir == "" {
+		return fallback
+	}
+	return ExpandHome(t.WorkDir)
+}
+
+// ParseModel parses "provider/model" (model IDs may themselves contain slashes)
+// Returns nil for an empty string
+func ParseModel(s string) (*ModelConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+	provider, model, ok := strings.Cut(s, "/")
+	if !ok || provider == "" || model == "" {
+		return nil, fmt.Errorf("invalid model %q, expected provider/model", s)
+	}
+	return &ModelConfig{ProviderID: provider, ModelID: model}, nil
+}
+
+// ExpandHome replaces a leading ~ with $HOME
+func ExpandHome(path string) string {
+	if path == "~" {
+		return os.Getenv("HOME")
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+	return path
+}
+
+func envString(key string, dst *string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+func envInt(key string, dst *int) {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			*dst = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[oc-tools] Warning: ignoring %s=%q: not a number\n", key, v)
+		}
+	}
+}
+
+func envDuration(key string, dst *Duration) {
+	if v := os.Getenv(key); v != "" {
+		if err := dst.Set(v); err != nil {
+			fmt.Fprintf(os.Stderr, "[oc-tools] Warning: ignoring %s: %v\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationSet(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"90s", 90 * time.Second, false},
+		{"15m", 15 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"soon", 0, true},
+		{"10", 0, true},
+	}
+	for _, tt := range tests {
+		var d Duration
+		err := d.Set(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && time.Duration(d) != tt.want {
+			t.Errorf("Set(%q) = %v, want %v", tt.in, time.Duration(d), tt.want)
+		}
+	}
+}
+
+func TestConfigToolTimeout(t *testing.T) {
+	cfg := &Config{
+		Timeout: Duration(10 * time.Minute),
+		Tools: map[string]ToolConfig{
+			"test-oracle": {Timeout: Duration(45 * time.Minute)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		builtin time.Duration
+		env     string // OC_TOOLS_<TOOL>_TIMEOUT, if set
+		want    time.Duration
+	}{
+		{"global timeout beats the built-in one", "test-tool", 30 * time.Minute, "", 10 * time.Minute},
+		{"global timeout without a built-in one", "test-tool", 0, "", 10 * time.Minute},
+		{"tool section beats the global timeout", "test-oracle", 30 * time.Minute, "", 45 * time.Minute},
+		{"tool section without a built-in timeout", "test-oracle", 0, "", 45 * time.Minute},
+		{"env beats the global timeout", "test-tool", 30 * time.Minute, "5m", 5 * time.Minute},
+		{"env beats the tool section", "test-oracle", 30 * time.Minute, "5m", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("OC_TOOLS_"+strings.ToUpper(strings.ReplaceAll(tt.tool, "-", "_"))+"_TIMEOUT", tt.env)
+			}
+			got := cfg.Tool(tt.tool, ToolConfig{Timeout: Duration(tt.builtin)})
+			if time.Duration(got.Timeout) != tt.want {
+				t.Errorf("Tool(%q).Timeout = %v, want %v", tt.tool, time.Duration(got.Timeout), tt.want)
+			}
+		})
+	}
+
+	// Without a global timeout the built-in one applies, and without either the default
+	if got := (&Config{}).Tool("test-tool", ToolConfig{Timeout: Duration(30 * time.Minute)}); time.Duration(got.Timeout) != 30*time.Minute {
+		t.Errorf("Tool().Timeout = %v, want the built-in 30m", time.Duration(got.Timeout))
+	}
+	if got := (&Config{}).Tool("test-tool", ToolConfig{}); time.Duration(got.Timeout) != DefaultTimeout {
+		t.Errorf("Tool().Timeout = %v, want %v", time.Duration(got.Timeout), DefaultTimeout)
+	}
+}
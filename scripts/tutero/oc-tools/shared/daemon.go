@@ -0,0 +1,359 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultIdleTimeout  = 15 * time.Minute // Keep-warm period after the last lease is released
+	daemonCheckInterval = 2 * time.Second
+)
+
+// DaemonState is written to daemon.json in the server state dir by the keep-warm daemon
+// URL is empty while the server is still starting; Error is set if startup failed.
+// Port is where the server actually listens, which differs from the state dir's port
+// when that one was taken by another program.
+type DaemonState struct {
+	PID         int           `json:"pid"`
+	ServerPID   int           `json:"server_pid,omitempty"`
+	Port        int           `json:"port"`
+	URL         string        `json:"url,omitempty"`
+	StartedAt   time.Time     `json:"started_at"`
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	Error       string        `json:"error,omitempty"`
+	Hint        string        `json:"hint,omitempty"` // Actionable explanation of Error
+	Tail        []string      `json:"tail,omitempty"` // Last server output lines of a failed startup
+}
+
+// alive reports whether the daemon process that wrote this state still exists
+func (s *DaemonState) alive() bool {
+	return processAlive(s.PID)
+}
+
+// readDaemonState loads daemon.json from dir, returning nil if absent or unreadable
+func readDaemonState(dir string) *DaemonState {
+	data, err := os.ReadFile(filepath.Join(dir, "daemon.json"))
+	if err != nil {
+		return nil
+	}
+	var state DaemonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
+}
+
+// writeDaemonState atomically replaces daemon.json in dir
+func writeDaemonState(dir string, state *DaemonState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, fmt.Sprintf("daemon.json.%d", os.Getpid()))
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, "daemon.json"))
+}
+
+// IdleTimeout returns the daemon idle timeout from config (idle_timeout) or
+// OC_TOOLS_IDLE_TIMEOUT (e.g. "30m", "0" to stop as soon as the last tool finishes)
+func IdleTimeout() time.Duration {
+	return time.Duration(*LoadConfig().IdleTimeout)
+}
+
+// HandleDaemonFlag runs the keep-warm server daemon and exits if args (os.Args[1:]) start with --daemon
+// Run calls it after IsolateDataDir for every tool
+//
+//	big-brain --daemon [--port N] [--idle-timeout 30m]
+func HandleDaemonFlag(args []string) {
+	if len(args) < 1 || args[0] != "--daemon" {
+		return
+	}
+
+	port := LoadConfig().ServerPort(os.Getenv("OPENCODE_SDK_ISOLATED") == "1")
+
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	fs.IntVar(&port, "port", port, "Port for opencode serve")
+	idleTimeout := fs.Duration("idle-timeout", IdleTimeout(), "Stop after this long without leases")
+	fs.Parse(args[1:])
+
+	if err := RunDaemon(port, *idleTimeout); err != nil {
+		fmt.Fprintf(os.Stderr, "[daemon] Error: %v\n", err)
+		var startErr *ServerStartError
+		if errors.As(err, &startErr) {
+			startErr.Print(os.Stderr)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runEnv lists the environment variables that belong to one tool run rather than to the
+// server: the daemon and its opencode server outlive the run, and every command an agent
+// runs would inherit them
+var runEnv = map[string]bool{
+	EnvServerURL:               true,
+	"OPENCODE_URL":             true,
+	EnvParentSession:           true,
+	EnvLogDir:                  true,
+	"_OC_TOOLS_DAEMON_SPAWNED": true,
+}
+
+// RunEnv marks an environment variable as belonging to one tool run (e.g. a recursion
+// guard set in Prepare) so the server daemon is started without it; returns name
+func RunEnv(name string) string {
+	runEnv[name] = true
+	return name
+}
+
+// daemonEnv returns env without the variables of the run that happens to start the daemon
+func daemonEnv(env []string) []string {
+	var kept []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !runEnv[name] {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+// spawnDaemon starts `<this executable> --daemon` detached from the caller's session
+// Caller must hold the server lock; the PID-only state it writes tells concurrent
+// starters a daemon is on its way so they wait instead of spawning another
+func spawnDaemon(dir string, port int) (*DaemonState, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable for daemon: %w", err)
+	}
+
+	cmd := exec.Command(exe, "--daemon", fmt.Sprintf("--port=%d", port))
+	cmd.Env = append(daemonEnv(os.Environ()), "_OC_TOOLS_DAEMON_SPAWNED=1")
+	// New session: survives the spawning tool's exit, Ctrl-C and terminal hangup
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+	// Reap in the background in case the daemon dies while we are still running
+	go cmd.Wait()
+
+	state := &DaemonState{
+		PID:       cmd.Process.Pid,
+		Port:      port,
+		StartedAt: time.Now(),
+	}
+	if err := writeDaemonState(dir, state); err != nil {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	return state, nil
+}
+
+// waitForDaemon polls daemon.json until the daemon with pid reports its server URL
+// Failures come back as *ServerStartError with the tail of server.log attached
+func waitForDaemon(ctx context.Context, dir string, pid, port int) (*DaemonState, error) {
+	deadline := time.After(ServerStartupTimeout + 5*time.Second)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Prefer the daemon's own report; fall back to the log tail if it died without one
+	fail := func(err error, state *DaemonState) (*DaemonState, error) {
+		var tail []string
+		if state != nil {
+			tail = state.Tail
+		} else {
+			tail = tailLines(ServerLogPath(dir), ServerLogTailLines)
+		}
+		startErr := newServerStartError(err, port, tail)
+		if state != nil && state.Hint != "" {
+			startErr.Hint = state.Hint
+		}
+		startErr.LogPath = ServerLogPath(dir)
+		return nil, startErr
+	}
+
+	for {
+		state := readDaemonState(dir)
+		if state != nil && state.PID == pid {
+			if state.URL != "" {
+				return state, nil
+			}
+			if state.Error != "" {
+				return fail(errors.New(state.Error), state)
+			}
+		}
+		if !processAlive(pid) {
+			return fail(fmt.Errorf("server daemon exited during startup"), nil)
+		}
+
+		select {
+		case <-deadline:
+			return fail(errServerStartupTimeout, nil)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDaemon owns an `opencode serve` on port and keeps it warm for later tool invocations
+// It stops the server and exits once no live lease has been held for idleTimeout
+// (0 = as soon as the last lease is released), when the server dies, or on SIGINT/SIGTERM.
+func RunDaemon(port int, idleTimeout time.Duration) error {
+	dir := ServerStateDir(port)
+	spawned := os.Getenv("_OC_TOOLS_DAEMON_SPAWNED") == "1"
+	os.Unsetenv("_OC_TOOLS_DAEMON_SPAWNED")
+
+	logger, err := NewLogger("opencode-daemon")
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+	logger.Log("Port: %d, idle timeout: %v, state dir: %s", port, idleTimeout, dir)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	state := &DaemonState{
+		PID:         os.Getpid(),
+		Port:        port,
+		StartedAt:   time.Now(),
+		IdleTimeout: idleTimeout,
+	}
+
+	// When started by hand, claim the state dir ourselves (a spawning client already did)
+	if !spawned {
+		lock, err := lockServerDir(ctx, dir)
+		if err != nil {
+			return err
+		}
+		if existing := readDaemonState(dir); existing != nil && existing.alive() {
+			lock.Unlock()
+			fmt.Fprintf(os.Stderr, "[daemon] Already running (pid %d) at %s\n", existing.PID, existing.URL)
+			return nil
+		}
+		err = writeDaemonState(dir, state)
+		lock.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to write daemon state: %w", err)
+		}
+	}
+
+	// Never start next to (or talk to) a program that merely holds our port - move aside instead
+	listenPort := port
+	switch probeServer(LoadConfig().ServerURL(port)) {
+	case probeOpencode:
+		if !spawned {
+			// A plain `opencode serve` already owns the port; clients will use it directly
+			os.Remove(filepath.Join(dir, "daemon.json"))
+			fmt.Fprintf(os.Stderr, "[daemon] An unmanaged opencode server already listens on port %d, nothing to do\n", port)
+			return nil
+		}
+		fallthrough
+	case probeForeign:
+		if listenPort, err = freePort(); err != nil {
+			return err
+		}
+		logger.Log("Port %d is taken by another listener, using free port %d", port, listenPort)
+	}
+
+	startCtx, cancelStart := context.WithTimeout(ctx, ServerStartupTimeout+time.Second)
+	cmd, url, err := startServer(startCtx, dir, listenPort)
+	cancelStart()
+
+	// The port can also be taken by something that does not speak HTTP (or between probe and bind)
+	var startErr *ServerStartError
+	if errors.As(err, &startErr) && startErr.Kind == ServerFailurePortInUse && listenPort == port {
+		if listenPort, err = freePort(); err != nil {
+			return err
+		}
+		logger.Log("Port %d in use, retrying on free port %d", port, listenPort)
+		startCtx, cancelStart = context.WithTimeout(ctx, ServerStartupTimeout+time.Second)
+		cmd, url, err = startServer(startCtx, dir, listenPort)
+		cancelStart()
+	}
+	if err != nil {
+		logger.Log("ERROR: server startup failed: %v", err)
+		state.Error = err.Error()
+		if errors.As(err, &startErr) {
+			state.Error = startErr.Err.Error()
+			state.Hint = startErr.Hint
+			state.Tail = startErr.Tail
+			startErr.LogPath = ServerLogPath(dir)
+			for _, line := range startErr.Tail {
+				logger.Log("[SERVER] %s", line)
+			}
+		}
+		writeDaemonState(dir, state)
+		return err
+	}
+
+	// Record where we actually listen so the next invocation finds us even off the default port
+	state.ServerPID = cmd.Process.Pid
+	state.Port = listenPort
+	state.URL = url
+	if err := writeDaemonState(dir, state); err != nil {
+		stopServer(state.ServerPID)
+		return fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	logger.Log("Server started at %s (pid %d)", url, state.ServerPID)
+	fmt.Fprintf(os.Stderr, "[daemon] Serving %s (idle timeout %v)\n", url, idleTimeout)
+
+	shutdown := func(reason string) {
+		logger.Log("Shutting down: %s", reason)
+		stopServer(state.ServerPID)
+		// Only clear the state if it is still ours
+		if current := readDaemonState(dir); current != nil && current.PID == state.PID {
+			os.Remove(filepath.Join(dir, "daemon.json"))
+		}
+	}
+
+	ticker := time.NewTicker(daemonCheckInterval)
+	defer ticker.Stop()
+	lastActive := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown("signal received")
+			return nil
+		case <-ticker.C:
+		}
+
+		if !processAlive(state.ServerPID) {
+			shutdown("server process exited")
+			return fmt.Errorf("opencode server exited unexpectedly")
+		}
+
+		lock, err := lockServerDir(ctx, dir)
+		if err != nil {
+			continue
+		}
+		leases := liveLeases(dir)
+		if leases > 0 {
+			lastActive = time.Now()
+			lock.Unlock()
+			continue
+		}
+		if idle := time.Since(lastActive); idle >= idleTimeout {
+			// Shut down while holding the lock so no client leases a dying server
+			shutdown(fmt.Sprintf("idle for %v", idle.Round(time.Second)))
+			lock.Unlock()
+			return nil
+		}
+		lock.Unlock()
+	}
+}
@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDaemonEnv(t *testing.T) {
+	guard := RunEnv("_TEST_TOOL_RUNNING")
+	env := []string{
+		"HOME=/home/me",
+		"PATH=/usr/bin",
+		guard + "=1",
+		EnvParentSession + "=ses_parent",
+		EnvServerURL + "=http://localhost:4097",
+		"OPENCODE_URL=http://localhost:4097",
+		EnvLogDir + "=/tmp/logs",
+		"XDG_DATA_HOME=/home/me/.cache/scripts/opencode-data",
+		"OPENCODE_SDK_ISOLATED=1",
+		"_OC_TOOLS_DAEMON_SPAWNED=1",
+	}
+	want := []string{
+		"HOME=/home/me",
+		"PATH=/usr/bin",
+		"XDG_DATA_HOME=/home/me/.cache/scripts/opencode-data",
+		"OPENCODE_SDK_ISOLATED=1",
+	}
+	if got := daemonEnv(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("daemonEnv = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+// ForkPoint is where --fork branches off an existing session: right after MessageID,
+// or after its last message if MessageID is empty
+type ForkPoint struct {
+	SessionID string
+	MessageID string
+}
+
+// ParseForkPoint reads SESSION or SESSION:MESSAGE
+func ParseForkPoint(s string) (*ForkPoint, error) {
+	sessionID, messageID, _ := strings.Cut(s, ":")
+	if sessionID == "" || strings.HasSuffix(s, ":") {
+		return nil, fmt.Errorf("invalid fork point %q (want SESSION or SESSION:MESSAGE)", s)
+	}
+	return &ForkPoint{SessionID: sessionID, MessageID: messageID}, nil
+}
+
+func (f *ForkPoint) String() string {
+	if f.MessageID == "" {
+		return f.SessionID
+	}
+	return f.SessionID + ":" + f.MessageID
+}
+
+// ForkSession creates a new session holding a copy of fork.SessionID's history up to
+// and including fork.MessageID. The server copies the messages *before* the one it is
+// given, so the message after MessageID is what gets sent.
+func (c *Client) ForkSession(fork *ForkPoint, workDir string) (*opencode.Session, error) {
+	body := map[string]string{}
+	if fork.MessageID != "" {
+		ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
+		messages, err := c.Session.Messages(ctx, fork.SessionID, opencode.SessionMessagesParams{
+			Directory: opencode.F(workDir),
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", fork.SessionID, err)
+		}
+		found := false
+		for i, message := range *messages {
+			if message.Info.ID != fork.MessageID {
+				continue
+			}
+			found = true
+			if i+1 < len(*messages) {
+				body["messageID"] = (*messages)[i+1].Info.ID
+			}
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("no such message (see oc sessions show %s for message IDs)", fork.SessionID)
+		}
+	}
+
+	// opencode-sdk-go has no Fork yet; same endpoint the opencode TUI uses
+	var session opencode.Session
+	path := fmt.Sprintf("session/%s/fork", fork.SessionID)
+	if err := c.Client.Post(c.ctx, path, body, &session, option.WithQuery("directory", workDir)); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
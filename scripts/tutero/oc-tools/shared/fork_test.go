@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+func TestParseForkPoint(t *testing.T) {
+	tests := []struct {
+		in        string
+		sessionID string
+		messageID string
+		wantErr   bool
+	}{
+		{"ses_abc", "ses_abc", "", false},
+		{"ses_abc:msg_123", "ses_abc", "msg_123", false},
+		{"", "", "", true},
+		{":msg_123", "", "", true},
+		{"ses_abc:", "", "", true},
+		{":", "", "", true},
+	}
+	for _, tt := range tests {
+		fork, err := ParseForkPoint(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseForkPoint(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if fork.SessionID != tt.sessionID || fork.MessageID != tt.messageID {
+			t.Errorf("ParseForkPoint(%q) = %+v", tt.in, fork)
+		}
+		if fork.String() != tt.in {
+			t.Errorf("ParseForkPoint(%q).String() = %q", tt.in, fork.String())
+		}
+	}
+}
+
+// forkServer answers the messages and fork endpoints like opencode, recording the
+// messageID each fork was asked for
+func forkServer(t *testing.T, forkedAt *[]string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/session/ses_src/message":
+			fmt.Fprint(w, `[
+				{"info": {"id": "msg_1", "role": "user", "sessionID": "ses_src"}, "parts": []},
+				{"info": {"id": "msg_2", "role": "assistant", "sessionID": "ses_src"}, "parts": []},
+				{"info": {"id": "msg_3", "role": "user", "sessionID": "ses_src"}, "parts": []}
+			]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/session/ses_src/fork":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			*forkedAt = append(*forkedAt, body["messageID"])
+			fmt.Fprint(w, `{"id": "ses_fork"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{
+		Client: opencode.NewClient(option.WithBaseURL(srv.URL), option.WithMaxRetries(0)),
+		ctx:    context.Background(),
+	}
+}
+
+func TestForkSession(t *testing.T) {
+	tests := []struct {
+		messageID string
+		want      string // messageID sent to the fork endpoint: the server copies what is before it
+	}{
+		{"", ""},           // The whole session
+		{"msg_1", "msg_2"}, // Up to and including msg_1
+		{"msg_2", "msg_3"},
+		{"msg_3", ""}, // The last message: everything
+	}
+	for _, tt := range tests {
+		var forkedAt []string
+		c := forkServer(t, &forkedAt)
+		session, err := c.ForkSession(&ForkPoint{SessionID: "ses_src", MessageID: tt.messageID}, "/tmp")
+		if err != nil {
+			t.Errorf("ForkSession(%q): %v", tt.messageID, err)
+			continue
+		}
+		if session.ID != "ses_fork" || len(forkedAt) != 1 || forkedAt[0] != tt.want {
+			t.Errorf("ForkSession(%q) forked at %q, want %q", tt.messageID, forkedAt, tt.want)
+		}
+	}
+
+	var forkedAt []string
+	c := forkServer(t, &forkedAt)
+	if _, err := c.ForkSession(&ForkPoint{SessionID: "ses_src", MessageID: "msg_9"}, "/tmp"); err == nil || len(forkedAt) > 0 {
+		t.Errorf("ForkSession(msg_9) = %v, forked %v; want an error and no fork", err, forkedAt)
+	}
+}
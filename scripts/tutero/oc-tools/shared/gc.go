@@ -0,0 +1,297 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// DefaultGCMaxAge is how long logs and sessions are kept without use (config: [gc] max_age)
+const DefaultGCMaxAge = 30 * 24 * time.Hour
+
+// How often auto_gc runs at most
+const autoGCInterval = 24 * time.Hour
+
+// GCPolicy is how much of a tool's history oc gc keeps
+type GCPolicy struct {
+	MaxAge    *Duration `toml:"max_age" json:"max_age"`         // Logs and sessions unused for longer are removed (0 = keep forever)
+	MaxSizeMB int       `toml:"max_size_mb" json:"max_size_mb"` // Cap on the tool's log dir; oldest logs go first (0 = no cap)
+}
+
+// GCPolicy returns the retention for a tool: [gc], with what [tools.<name>.gc] sets on top
+func (c *Config) GCPolicy(tool string) GCPolicy {
+	policy := c.GC
+	if section, ok := c.Tools[tool]; ok && section.GC != nil {
+		if section.GC.MaxAge != nil {
+			policy.MaxAge = section.GC.MaxAge
+		}
+		if section.GC.MaxSizeMB != 0 {
+			policy.MaxSizeMB = section.GC.MaxSizeMB
+		}
+	}
+	return policy
+}
+
+// cutoff returns the time before which things are expired (zero if they never are)
+func (p GCPolicy) cutoff(now time.Time) time.Time {
+	if p.MaxAge == nil || *p.MaxAge == 0 {
+		return time.Time{}
+	}
+	return now.Add(-time.Duration(*p.MaxAge))
+}
+
+// GCItem is something oc gc removed, or would remove in a dry run
+type GCItem struct {
+	Kind   string `json:"kind"` // log, link or session
+	Tool   string `json:"tool,omitempty"`
+	Target string `json:"target"` // File path or session ID
+	Size   int64  `json:"size,omitempty"`
+	Reason string `json:"reason"`
+	Error  string `json:"error,omitempty"` // Set if removing it failed
+
+	workDir string // Directory a session is deleted in
+}
+
+// remove deletes a file unless this is a dry run, noting any failure on the item
+func (item *GCItem) remove(dryRun bool) {
+	if dryRun {
+		return
+	}
+	if err := os.Remove(item.Target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		item.Error = err.Error()
+	}
+}
+
+// GCLogs applies a tool's retention to its log dir: logs untouched for longer than
+// MaxAge go, then the oldest until the dir fits in MaxSizeMB, then the session-*.log
+// links left pointing at nothing
+func GCLogs(tool string, policy GCPolicy, dryRun bool) []GCItem {
+	dir := LogDir(tool)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	type logFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var links []string
+	var kept []logFile
+	var items []GCItem
+	removed := map[string]bool{}
+	cutoff := policy.cutoff(time.Now())
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		if entry.Type()&os.ModeSymlink != 0 {
+			links = append(links, path)
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			item := GCItem{Kind: "log", Tool: tool, Target: path, Size: info.Size(),
+				Reason: "unused for " + shortAge(time.Since(info.ModTime()))}
+			item.remove(dryRun)
+			items = append(items, item)
+			removed[path] = true
+			continue
+		}
+		kept = append(kept, logFile{path, info.Size(), info.ModTime()})
+	}
+
+	if policy.MaxSizeMB > 0 {
+		// Newest first; everything past the cap goes
+		sort.Slice(kept, func(i, j int) bool { return kept[i].modTime.After(kept[j].modTime) })
+		var total int64
+		limit := int64(policy.MaxSizeMB) << 20
+		for _, log := range kept {
+			total += log.size
+			if total <= limit {
+				continue
+			}
+			item := GCItem{Kind: "log", Tool: tool, Target: log.path, Size: log.size,
+				Reason: fmt.Sprintf("over the %d MB cap", policy.MaxSizeMB)}
+			item.remove(dryRun)
+			items = append(items, item)
+			removed[log.path] = true
+		}
+	}
+
+	for _, link := range links {
+		target, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		reason := ""
+		if removed[target] {
+			reason = "its log is removed"
+		} else if _, err := os.Stat(target); errors.Is(err, os.ErrNotExist) {
+			reason = "dangling"
+		}
+		if reason == "" {
+			continue
+		}
+		item := GCItem{Kind: "link", Tool: tool, Target: link, Reason: reason}
+		item.remove(dryRun)
+		items = append(items, item)
+	}
+	return items
+}
+
+// GCSessions deletes the expired sessions of this client's server, by the retention
+// policyFor gives their tool. tool limits it to that tool's sessions ("" = all).
+// Registered sessions expire by their last prompt. On the isolated server, which only
+// tools use, unregistered sessions in the directories tools ran in expire by the
+// server's last update too; on the main server those could be the user's own, so they
+// are left alone. Expired records go too, even if the server had already lost the session.
+func (c *Client) GCSessions(policyFor func(tool string) GCPolicy, tool string, isolated, dryRun bool) []GCItem {
+	records, _ := ListSessionRecords()
+
+	// Unregistered sessions belong to no tool, so a tool filter leaves them alone
+	var listed map[string][]opencode.Session
+	if isolated && tool == "" {
+		listed = map[string][]opencode.Session{}
+		for _, rec := range records {
+			if !rec.Isolated || listed[rec.WorkDir] != nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
+			sessions, err := c.Session.List(ctx, opencode.SessionListParams{Directory: opencode.F(rec.WorkDir)})
+			cancel()
+			if err != nil {
+				c.log("Could not list sessions in %s: %v", rec.WorkDir, err)
+				listed[rec.WorkDir] = []opencode.Session{}
+				continue
+			}
+			listed[rec.WorkDir] = *sessions
+		}
+	}
+
+	items := expiredSessions(records, listed, policyFor, tool, isolated, time.Now())
+	if dryRun {
+		return items
+	}
+	for i := range items {
+		item := &items[i]
+		if err := c.DeleteSession(item.Target, item.workDir); err != nil && !IsNotFound(err) {
+			item.Error = err.Error()
+			continue
+		}
+		RemoveSessionRecord(item.Target)
+	}
+	return items
+}
+
+// expiredSessions picks the sessions GCSessions deletes: the records on the isolated
+// or main server whose tool matches (tool "" = any), and the sessions listed per
+// directory (nil = none) that have no record
+func expiredSessions(records []*SessionRecord, listed map[string][]opencode.Session, policyFor func(tool string) GCPolicy, tool string, isolated bool, now time.Time) []GCItem {
+	var items []GCItem
+	expire := func(id, tool string, lastUsed time.Time, workDir string) {
+		if !lastUsed.Before(policyFor(tool).cutoff(now)) {
+			return
+		}
+		items = append(items, GCItem{Kind: "session", Tool: tool, Target: id,
+			Reason: "unused for " + shortAge(now.Sub(lastUsed)), workDir: workDir})
+	}
+
+	registered := map[string]bool{}
+	for _, rec := range records {
+		if rec.Isolated != isolated {
+			continue
+		}
+		registered[rec.ID] = true
+		if tool == "" || rec.Tool == tool {
+			expire(rec.ID, rec.Tool, rec.Updated, rec.WorkDir)
+		}
+	}
+	if tool != "" {
+		return items
+	}
+
+	dirs := make([]string, 0, len(listed))
+	for dir := range listed {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		for _, session := range listed[dir] {
+			if !registered[session.ID] {
+				expire(session.ID, "", time.UnixMilli(int64(session.Time.Updated)), dir)
+			}
+		}
+	}
+	return items
+}
+
+// IsNotFound reports whether the server answered 404 (e.g. the session is already gone)
+func IsNotFound(err error) bool {
+	var apiErr *opencode.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 404
+}
+
+// shortAge formats an age in days, or hours under two days
+func shortAge(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// MaybeAutoGC starts `oc gc --auto` in the background if auto_gc is on and it hasn't
+// run for a day. The tool doesn't wait for it; its report goes to gc.log.
+// env is the tool's environment before IsolateDataDir, so gc picks servers like a shell would
+func MaybeAutoGC(env []string) {
+	if !LoadConfig().AutoGC {
+		return
+	}
+	stamp := filepath.Join(CacheDir(), "gc.stamp")
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < autoGCInterval {
+		return
+	}
+
+	// Claim the run first so tools starting together don't all spawn one
+	os.MkdirAll(CacheDir(), 0755)
+	if err := os.WriteFile(stamp, []byte(time.Now().Format(time.RFC3339)+"\n"), 0644); err != nil {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	logFile, err := os.OpenFile(filepath.Join(CacheDir(), "gc.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "gc", "--auto")
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	cmd.Env = env
+	// New session: survives the tool's exit and Ctrl-C
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err == nil {
+		// Reap it if it finishes while the tool is still running
+		go cmd.Wait()
+	}
+}
@@ -0,0 +1,94 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestExpiredSessions(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	age := Duration(30 * 24 * time.Hour)
+	keep := Duration(0)
+	policyFor := func(tool string) GCPolicy {
+		if tool == "db-oracle" {
+			return GCPolicy{MaxAge: &keep}
+		}
+		return GCPolicy{MaxAge: &age}
+	}
+
+	records := []*SessionRecord{
+		{ID: "ses_web_old", Tool: "web-search", WorkDir: "/tmp", Isolated: true, Updated: old},
+		{ID: "ses_web_new", Tool: "web-search", WorkDir: "/tmp", Isolated: true, Updated: recent},
+		{ID: "ses_brain_old", Tool: "big-brain", WorkDir: "/repo", Isolated: true, Updated: old},
+		{ID: "ses_db_old", Tool: "db-oracle", WorkDir: "/meta", Isolated: true, Updated: old},
+		{ID: "ses_namer_old", Tool: "branch-namer", WorkDir: "/repo", Isolated: false, Updated: old},
+	}
+	listed := map[string][]opencode.Session{
+		"/tmp": {
+			{ID: "ses_web_old"}, // Registered: expires by its record
+			{ID: "ses_stray_old", Time: opencode.SessionTime{Updated: float64(old.UnixMilli())}},
+			{ID: "ses_stray_new", Time: opencode.SessionTime{Updated: float64(recent.UnixMilli())}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		tool     string
+		isolated bool
+		listed   map[string][]opencode.Session
+		want     []string
+	}{
+		{"isolated, all tools", "", true, listed, []string{"ses_web_old", "ses_brain_old", "ses_stray_old"}},
+		{"isolated, one tool", "web-search", true, listed, []string{"ses_web_old"}},
+		{"tool with no expired sessions", "db-oracle", true, listed, nil},
+		{"main server", "", false, nil, []string{"ses_namer_old"}},
+		{"main server, other tool", "web-search", false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range expiredSessions(records, tt.listed, policyFor, tt.tool, tt.isolated, now) {
+				got = append(got, item.Target)
+				if item.Kind != "session" || item.workDir == "" {
+					t.Errorf("item %+v: want a session with its workdir", item)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCPolicy(t *testing.T) {
+	global := Duration(30 * 24 * time.Hour)
+	long := Duration(90 * 24 * time.Hour)
+	cfg := &Config{
+		GC: GCPolicy{MaxAge: &global, MaxSizeMB: 100},
+		Tools: map[string]ToolConfig{
+			"db-oracle":  {GC: &GCPolicy{MaxAge: &long}},
+			"web-search": {GC: &GCPolicy{MaxSizeMB: 10}},
+		},
+	}
+
+	tests := []struct {
+		tool    string
+		maxAge  Duration
+		maxSize int
+	}{
+		{"big-brain", global, 100},
+		{"db-oracle", long, 100},
+		{"web-search", global, 10},
+	}
+	for _, tt := range tests {
+		policy := cfg.GCPolicy(tt.tool)
+		if *policy.MaxAge != tt.maxAge || policy.MaxSizeMB != tt.maxSize {
+			t.Errorf("GCPolicy(%q) = %v/%d MB, want %v/%d MB", tt.tool, *policy.MaxAge, policy.MaxSizeMB, tt.maxAge, tt.maxSize)
+		}
+	}
+}
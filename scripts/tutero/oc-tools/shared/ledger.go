@@ -0,0 +1,79 @@
+package shared
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LedgerEntry is one tool run as recorded in the usage ledger
+type LedgerEntry struct {
+	Time      time.Time `json:"time"` // When the run finished
+	Tool      string    `json:"tool"`
+	SessionID string    `json:"session_id"`
+	Model     string    `json:"model,omitempty"` // provider/model that answered
+	WorkDir   string    `json:"workdir"`         // Where the tool was run from
+	Usage     Usage     `json:"usage"`
+	Cost      float64   `json:"cost"` // USD
+	Elapsed   Duration  `json:"elapsed"`
+	Partial   bool      `json:"partial,omitempty"`
+	Error     string    `json:"error,omitempty"` // Why the run failed or was stopped (empty if it answered)
+}
+
+// LedgerPath returns the usage ledger: one JSON object per line, appended by every run
+func LedgerPath() string {
+	return filepath.Join(CacheDir(), "usage.jsonl")
+}
+
+// RecordUsage appends a finished run to the usage ledger, failed ones included: a run
+// that errored, timed out or was interrupted has still been billed for what it used
+// Each entry is a single O_APPEND write, so concurrent tools don't interleave lines
+func RecordUsage(entry LedgerEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := LedgerPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create ledger dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// ReadLedger returns every entry in the usage ledger, oldest first
+// Lines that don't parse (e.g. cut short by a full disk) are skipped
+func ReadLedger() ([]LedgerEntry, error) {
+	f, err := os.Open(LedgerPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []LedgerEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry LedgerEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return entries, nil
+}
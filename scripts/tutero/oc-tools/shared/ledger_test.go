@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLedger(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if entries, err := ReadLedger(); entries != nil || err != nil {
+		t.Fatalf("ReadLedger() without a ledger = %v, %v; want nothing", entries, err)
+	}
+
+	finished := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := []LedgerEntry{
+		{Time: finished, Tool: "big-brain", SessionID: "ses_1", Model: "anthropic/claude-opus-4-1", WorkDir: "/repo",
+			Usage: Usage{Input: 1000, Output: 200, CacheRead: 50}, Cost: 0.03, Elapsed: Duration(90 * time.Second)},
+		{Time: finished.Add(time.Minute), Tool: "web-search", SessionID: "ses_2", WorkDir: "/tmp",
+			Usage: Usage{Input: 300}, Cost: 0.001, Elapsed: Duration(time.Second), Error: "interrupted by interrupt"},
+		{Time: finished.Add(2 * time.Minute), Tool: "db-oracle", SessionID: "ses_3", WorkDir: "/meta",
+			Usage: Usage{Output: 40}, Partial: true, Error: "budget exceeded"},
+	}
+	for _, entry := range want {
+		if err := RecordUsage(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A line cut short (e.g. by a full disk) is skipped, not fatal
+	f, err := os.OpenFile(LedgerPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time": "2026-03-01T12:03:00Z", "tool": "big-br` + "\n")
+	f.Close()
+
+	got, err := ReadLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLedger() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLedgerConcurrentRuns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RecordUsage(LedgerEntry{Time: time.Now(), Tool: "big-brain", SessionID: "ses_1", Usage: Usage{Input: 1}})
+		}()
+	}
+	wg.Wait()
+
+	entries, err := ReadLedger()
+	if err != nil || len(entries) != 20 {
+		t.Errorf("ReadLedger() = %d entries, %v; want all 20", len(entries), err)
+	}
+}
@@ -0,0 +1,40 @@
+package shared
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// User message IDs are chosen here rather than by the server so a run knows its own
+// prompts: their answers are the assistant messages whose parent they are.
+
+var (
+	messageIDMu   sync.Mutex
+	messageIDLast int64 // Millisecond of the last ID
+	messageIDSeq  int64 // IDs made in that millisecond
+)
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// newMessageID returns an ID in the format of opencode's Identifier.ascending("message"):
+// "msg_", 12 hex digits of (milliseconds × 4096 + sequence), then 14 random base62
+// characters. opencode orders a session's messages by ID, so the time part must sort
+// like the server's own IDs do.
+func newMessageID() string {
+	messageIDMu.Lock()
+	now := time.Now().UnixMilli()
+	if now != messageIDLast {
+		messageIDLast, messageIDSeq = now, 0
+	}
+	messageIDSeq++
+	stamp := now*0x1000 + messageIDSeq
+	messageIDMu.Unlock()
+
+	suffix := make([]byte, 14)
+	for i := range suffix {
+		suffix[i] = base62[rand.IntN(len(base62))]
+	}
+	return fmt.Sprintf("msg_%012x%s", stamp&0xffffffffffff, suffix)
+}
@@ -0,0 +1,264 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// DefaultModelCacheTTL is how long the server's model list is reused (config: model_cache_ttl)
+const DefaultModelCacheTTL = time.Hour
+
+// ModelInfo is what model selection knows about a model the server offers
+type ModelInfo struct {
+	ProviderID  string  `json:"provider_id"`
+	ModelID     string  `json:"model_id"`
+	Name        string  `json:"name"`
+	ReleaseDate string  `json:"release_date"`
+	InputCost   float64 `json:"input_cost"`  // USD per million tokens
+	OutputCost  float64 `json:"output_cost"` // USD per million tokens
+	Context     float64 `json:"context"`     // Context window in tokens (0 = unknown)
+	ToolCall    bool    `json:"tool_call"`
+	Reasoning   bool    `json:"reasoning"`
+	Attachment  bool    `json:"attachment"`
+}
+
+// Model returns the provider/model pair to prompt with
+func (m ModelInfo) Model() *ModelConfig {
+	return &ModelConfig{ProviderID: m.ProviderID, ModelID: m.ModelID}
+}
+
+// Free reports whether the model costs nothing
+func (m ModelInfo) Free() bool {
+	return m.InputCost == 0 && m.OutputCost == 0
+}
+
+// Has reports whether the model has a capability: tool_call, reasoning or attachment
+func (m ModelInfo) Has(capability string) bool {
+	switch capability {
+	case "tool_call", "tools":
+		return m.ToolCall
+	case "reasoning":
+		return m.Reasoning
+	case "attachment", "attachments":
+		return m.Attachment
+	}
+	return false
+}
+
+// ModelPolicy picks models from what the server offers instead of naming one
+// Tools declare a built-in policy; [tools.<name>.policy] in the config replaces it:
+//
+//	[tools.big-brain.policy]
+//	providers       = ["anthropic", "openai"]
+//	max_output_cost = 20                 # USD per million tokens
+//	require         = ["reasoning", "tool_call"]
+//	min_context     = 200000
+//	prefer          = ["claude-opus", "gpt-5"]
+type ModelPolicy struct {
+	Providers     []string `toml:"providers" json:"providers"`           // Allowed providers (empty = any)
+	DenyProviders []string `toml:"deny_providers" json:"deny_providers"` // Never these providers
+	MaxInputCost  *float64 `toml:"max_input_cost" json:"max_input_cost"` // USD per million tokens (nil = no cap, 0 = free only)
+	MaxOutputCost *float64 `toml:"max_output_cost" json:"max_output_cost"`
+	Require       []string `toml:"require" json:"require"`         // Capabilities: tool_call, reasoning, attachment
+	MinContext    float64  `toml:"min_context" json:"min_context"` // Minimum context window in tokens
+	Prefer        []string `toml:"prefer" json:"prefer"`           // Model families, best first, matched against model IDs
+}
+
+// FreeModelPolicy is what FindBestFreeModel looks for: free opencode models that can call tools
+func FreeModelPolicy() *ModelPolicy {
+	free := 0.0
+	return &ModelPolicy{
+		Providers:     []string{"opencode"},
+		MaxInputCost:  &free,
+		MaxOutputCost: &free,
+		Require:       []string{"tool_call"},
+	}
+}
+
+// Allows reports whether a model passes every filter of the policy
+func (p *ModelPolicy) Allows(m ModelInfo) bool {
+	if len(p.Providers) > 0 && !slices.Contains(p.Providers, m.ProviderID) {
+		return false
+	}
+	if slices.Contains(p.DenyProviders, m.ProviderID) {
+		return false
+	}
+	if p.MaxInputCost != nil && m.InputCost > *p.MaxInputCost {
+		return false
+	}
+	if p.MaxOutputCost != nil && m.OutputCost > *p.MaxOutputCost {
+		return false
+	}
+	for _, capability := range p.Require {
+		if !m.Has(capability) {
+			return false
+		}
+	}
+	if p.MinContext > 0 && m.Context < p.MinContext {
+		return false
+	}
+	return true
+}
+
+// Rank returns the allowed models, best first: preferred families in order, then the
+// most recent release, then the cheapest
+func (p *ModelPolicy) Rank(models []ModelInfo) []ModelInfo {
+	var ranked []ModelInfo
+	for _, m := range models {
+		if p.Allows(m) {
+			ranked = append(ranked, m)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if pa, pb := p.preference(a), p.preference(b); pa != pb {
+			return pa < pb
+		}
+		if a.ReleaseDate != b.ReleaseDate {
+			return a.ReleaseDate > b.ReleaseDate
+		}
+		if ca, cb := a.InputCost+a.OutputCost, b.InputCost+b.OutputCost; ca != cb {
+			return ca < cb
+		}
+		return a.ProviderID+"/"+a.ModelID < b.ProviderID+"/"+b.ModelID
+	})
+	return ranked
+}
+
+// preference returns the index of the first preferred family the model belongs to
+// (len(Prefer) if none)
+func (p *ModelPolicy) preference(m ModelInfo) int {
+	for i, family := range p.Prefer {
+		family = strings.ToLower(family)
+		if strings.Contains(strings.ToLower(m.ModelID), family) || strings.Contains(strings.ToLower(m.Name), family) {
+			return i
+		}
+	}
+	return len(p.Prefer)
+}
+
+// String summarizes the policy for logs
+func (p *ModelPolicy) String() string {
+	var parts []string
+	if len(p.Providers) > 0 {
+		parts = append(parts, "providers="+strings.Join(p.Providers, ","))
+	}
+	if len(p.DenyProviders) > 0 {
+		parts = append(parts, "deny="+strings.Join(p.DenyProviders, ","))
+	}
+	if p.MaxInputCost != nil {
+		parts = append(parts, fmt.Sprintf("max_input_cost=%g", *p.MaxInputCost))
+	}
+	if p.MaxOutputCost != nil {
+		parts = append(parts, fmt.Sprintf("max_output_cost=%g", *p.MaxOutputCost))
+	}
+	if len(p.Require) > 0 {
+		parts = append(parts, "require="+strings.Join(p.Require, ","))
+	}
+	if p.MinContext > 0 {
+		parts = append(parts, fmt.Sprintf("min_context=%g", p.MinContext))
+	}
+	if len(p.Prefer) > 0 {
+		parts = append(parts, "prefer="+strings.Join(p.Prefer, ","))
+	}
+	if len(parts) == 0 {
+		return "any model"
+	}
+	return strings.Join(parts, " ")
+}
+
+// modelCache is the on-disk copy of a server's model list
+type modelCache struct {
+	URL       string      `json:"url"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Models    []ModelInfo `json:"models"`
+}
+
+// CacheDir returns where oc-tools keeps shared state: ~/.cache/scripts/oc-tools
+func CacheDir() string {
+	return LogDir("oc-tools")
+}
+
+// modelCachePath returns the cache file for the server at baseURL
+// Servers on different ports may run with different provider setups, so each gets its own
+func modelCachePath(baseURL string) string {
+	name := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	name = strings.NewReplacer(":", "-", "/", "-").Replace(name)
+	return filepath.Join(CacheDir(), "models-"+name+".json")
+}
+
+// ListModels returns every model the server offers, from the disk cache if it is
+// younger than the configured TTL (refresh skips the cache)
+func (c *Client) ListModels(refresh bool) ([]ModelInfo, error) {
+	path := modelCachePath(c.baseURL)
+	ttl := time.Duration(*LoadConfig().ModelCacheTTL)
+
+	if !refresh && ttl > 0 {
+		var cache modelCache
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cache) == nil &&
+			cache.URL == c.baseURL && time.Since(cache.FetchedAt) < ttl {
+			c.log("Using cached model list from %s (%d models, age %v)", path, len(cache.Models), time.Since(cache.FetchedAt).Round(time.Second))
+			return cache.Models, nil
+		}
+	}
+
+	c.log("Fetching model list from server...")
+	resp, err := c.App.Providers(c.ctx, opencode.AppProvidersParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get providers: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, provider := range resp.Providers {
+		for modelID, model := range provider.Models {
+			models = append(models, ModelInfo{
+				ProviderID:  provider.ID,
+				ModelID:     modelID,
+				Name:        model.Name,
+				ReleaseDate: model.ReleaseDate,
+				InputCost:   model.Cost.Input,
+				OutputCost:  model.Cost.Output,
+				Context:     model.Limit.Context,
+				// null means tool calls are supported; only an explicit false rules them out
+				ToolCall:   model.ToolCall || model.JSON.ToolCall.IsNull(),
+				Reasoning:  model.Reasoning,
+				Attachment: model.Attachment,
+			})
+		}
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].ProviderID+"/"+models[i].ModelID < models[j].ProviderID+"/"+models[j].ModelID
+	})
+	c.log("Server offers %d models", len(models))
+
+	if data, err := json.MarshalIndent(modelCache{URL: c.baseURL, FetchedAt: time.Now(), Models: models}, "", "  "); err == nil {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			c.log("Warning: could not cache model list: %v", err)
+		}
+	}
+	return models, nil
+}
+
+// SelectModels returns the models that satisfy policy, best first
+func (c *Client) SelectModels(policy *ModelPolicy) ([]ModelInfo, error) {
+	models, err := c.ListModels(false)
+	if err != nil {
+		return nil, err
+	}
+	ranked := policy.Rank(models)
+	c.log("Model policy (%s): %d of %d models match", policy, len(ranked), len(models))
+	return ranked, nil
+}
@@ -0,0 +1,115 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+// catalog is a model list like the fake server's providers offer
+var catalog = []ModelInfo{
+	{ProviderID: "opencode", ModelID: "free-a", ReleaseDate: "2025-01-01", Context: 128000, ToolCall: true},
+	{ProviderID: "opencode", ModelID: "free-b", ReleaseDate: "2025-06-01", Context: 200000, ToolCall: true, Reasoning: true},
+	{ProviderID: "opencode", ModelID: "free-notools", ReleaseDate: "2025-09-01", Context: 32000},
+	{ProviderID: "anthropic", ModelID: "claude-haiku-4-5", Name: "Haiku 4.5", ReleaseDate: "2025-10-01", InputCost: 1, OutputCost: 5, Context: 200000, ToolCall: true, Reasoning: true, Attachment: true},
+	{ProviderID: "anthropic", ModelID: "claude-opus-4-1", Name: "Opus 4.1", ReleaseDate: "2025-08-05", InputCost: 15, OutputCost: 75, Context: 200000, ToolCall: true, Reasoning: true, Attachment: true},
+	{ProviderID: "openai", ModelID: "gpt-5", ReleaseDate: "2025-08-07", InputCost: 1.25, OutputCost: 10, Context: 400000, ToolCall: true, Reasoning: true, Attachment: true},
+	{ProviderID: "openrouter", ModelID: "anthropic/claude-opus-4-1", ReleaseDate: "2025-08-05", InputCost: 15, OutputCost: 75, Context: 200000, ToolCall: true},
+}
+
+func cost(usd float64) *float64 { return &usd }
+
+func modelIDs(models []ModelInfo) string {
+	var ids []string
+	for _, m := range models {
+		ids = append(ids, m.ProviderID+"/"+m.ModelID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestModelPolicyRank(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *ModelPolicy
+		want   string
+	}{
+		{
+			name:   "any model, newest first",
+			policy: &ModelPolicy{},
+			want:   "anthropic/claude-haiku-4-5 opencode/free-notools openai/gpt-5 anthropic/claude-opus-4-1 openrouter/anthropic/claude-opus-4-1 opencode/free-b opencode/free-a",
+		},
+		{
+			name:   "free",
+			policy: FreeModelPolicy(),
+			want:   "opencode/free-b opencode/free-a",
+		},
+		{
+			name:   "providers",
+			policy: &ModelPolicy{Providers: []string{"anthropic", "openai"}},
+			want:   "anthropic/claude-haiku-4-5 openai/gpt-5 anthropic/claude-opus-4-1",
+		},
+		{
+			name:   "denied providers",
+			policy: &ModelPolicy{DenyProviders: []string{"opencode", "openrouter"}},
+			want:   "anthropic/claude-haiku-4-5 openai/gpt-5 anthropic/claude-opus-4-1",
+		},
+		{
+			name:   "cost caps",
+			policy: &ModelPolicy{MaxInputCost: cost(2), MaxOutputCost: cost(5)},
+			want:   "anthropic/claude-haiku-4-5 opencode/free-notools opencode/free-b opencode/free-a",
+		},
+		{
+			name:   "capabilities and context",
+			policy: &ModelPolicy{Require: []string{"reasoning", "attachments"}, MinContext: 300000},
+			want:   "openai/gpt-5",
+		},
+		{
+			name:   "preferred families first, in order",
+			policy: &ModelPolicy{Prefer: []string{"GPT-5", "opus"}, Require: []string{"tools"}},
+			want:   "openai/gpt-5 anthropic/claude-opus-4-1 openrouter/anthropic/claude-opus-4-1 anthropic/claude-haiku-4-5 opencode/free-b opencode/free-a",
+		},
+		{
+			name:   "preference matches the display name",
+			policy: &ModelPolicy{Prefer: []string{"haiku 4.5"}, Providers: []string{"anthropic"}},
+			want:   "anthropic/claude-haiku-4-5 anthropic/claude-opus-4-1",
+		},
+		{
+			name:   "same release, cheapest first",
+			policy: &ModelPolicy{Prefer: []string{"opus"}, DenyProviders: []string{"anthropic"}},
+			want:   "openrouter/anthropic/claude-opus-4-1 opencode/free-notools openai/gpt-5 opencode/free-b opencode/free-a",
+		},
+		{
+			name:   "nothing allowed",
+			policy: &ModelPolicy{Providers: []string{"google"}},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := modelIDs(tt.policy.Rank(catalog)); got != tt.want {
+				t.Errorf("Rank() with %s\n got %s\nwant %s", tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelPolicyRankTieBreaks(t *testing.T) {
+	models := []ModelInfo{
+		{ProviderID: "b", ModelID: "m", ReleaseDate: "2025-01-01", InputCost: 1},
+		{ProviderID: "a", ModelID: "m", ReleaseDate: "2025-01-01", InputCost: 1},
+		{ProviderID: "c", ModelID: "m", ReleaseDate: "2025-01-01"},
+	}
+	// Cheapest first, then by name so the order doesn't depend on the server's
+	if got, want := modelIDs((&ModelPolicy{}).Rank(models)), "c/m a/m b/m"; got != want {
+		t.Errorf("Rank() = %s, want %s", got, want)
+	}
+}
+
+func TestModelPolicyString(t *testing.T) {
+	if got := (&ModelPolicy{}).String(); got != "any model" {
+		t.Errorf("String() = %q, want any model", got)
+	}
+	want := "providers=opencode max_input_cost=0 max_output_cost=0 require=tool_call"
+	if got := FreeModelPolicy().String(); got != want {
+		t.Errorf("FreeModelPolicy().String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package shared
+
+import (
+	"context"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// PartialError is returned when a prompt failed or timed out after the agent had
+// already produced some text; Result holds that text and the usage so far
+type PartialError struct {
+	Result *AgentResult
+	Err    error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// answerParts fetches the parts of the assistant messages answering this run's prompts
+// (see runStats.answers), in order, adding them to stats. This is the run's whole
+// answer: a multi-step run spreads it over several messages. Returns nil if there are
+// none or the fetch fails. Uses its own short deadline because the run's context has
+// usually expired by the time a failed prompt gets here.
+func (c *Client) answerParts(sessionID, workDir string, stats *runStats) []opencode.Part {
+	c.log("Fetching the answer of session %s", sessionID)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messages, err := c.Session.Messages(ctx, sessionID, opencode.SessionMessagesParams{
+		Directory: opencode.F(workDir),
+	})
+	if err != nil {
+		c.log("Answer fetch error: %v", err)
+		return nil
+	}
+
+	var parts []opencode.Part
+	for _, message := range *messages {
+		assistant, ok := message.Info.AsUnion().(opencode.AssistantMessage)
+		if !ok || !stats.answers(assistant) {
+			continue
+		}
+		stats.observeMessage(assistant)
+		for _, part := range message.Parts {
+			stats.observePart(part)
+		}
+		parts = append(parts, message.Parts...)
+	}
+	c.log("Answer: %d parts", len(parts))
+	return parts
+}
@@ -0,0 +1,198 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// SessionRecord is what the session registry keeps about a session a tool created
+type SessionRecord struct {
+	ID         string    `json:"id"`
+	Tool       string    `json:"tool"`
+	Agent      string    `json:"agent,omitempty"`
+	System     string    `json:"system,omitempty"` // System prompt it was created with (oc-run --system), replayed on resume
+	Title      string    `json:"title"`
+	Summary    string    `json:"summary"` // First line of the first prompt, shortened
+	WorkDir    string    `json:"workdir"`
+	Branch     string    `json:"branch,omitempty"` // Git branch of WorkDir when the session was created
+	Model      string    `json:"model,omitempty"`  // provider/model that last answered
+	ServerURL  string    `json:"server_url"`
+	Isolated   bool      `json:"isolated"` // Lives on the isolated server (IsolateDataDir)
+	LogPath    string    `json:"log_path,omitempty"`
+	ForkedFrom string    `json:"forked_from,omitempty"` // Session this one was forked from (--fork)
+	ForkedAt   string    `json:"forked_at,omitempty"`   // Last message copied from it (empty = all of them)
+	Prompts    int       `json:"prompts"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"` // Last prompt sent
+}
+
+// Longest first-prompt summary kept in the registry
+const summaryLen = 100
+
+// SessionRegistryDir returns where session records live: ~/.cache/scripts/oc-tools/sessions
+func SessionRegistryDir() string {
+	return filepath.Join(CacheDir(), "sessions")
+}
+
+func sessionRecordPath(id string) string {
+	return filepath.Join(SessionRegistryDir(), filepath.Base(id)+".json")
+}
+
+// SaveSessionRecord writes rec to the registry, replacing any earlier version
+// Written to a temp file first so a concurrent reader never sees half a record
+func SaveSessionRecord(rec *SessionRecord) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(SessionRegistryDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create session registry: %w", err)
+	}
+	path := sessionRecordPath(rec.ID)
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session record: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSessionRecord reads a session's record; the error wraps os.ErrNotExist if there is none
+func LoadSessionRecord(id string) (*SessionRecord, error) {
+	data, err := os.ReadFile(sessionRecordPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var rec SessionRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("corrupt session record %s: %w", sessionRecordPath(id), err)
+	}
+	return &rec, nil
+}
+
+// RemoveSessionRecord drops a session from the registry (a missing record is not an error)
+func RemoveSessionRecord(id string) error {
+	err := os.Remove(sessionRecordPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ListSessionRecords returns every registered session, most recently used first
+func ListSessionRecords() ([]*SessionRecord, error) {
+	paths, err := filepath.Glob(filepath.Join(SessionRegistryDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var records []*SessionRecord
+	for _, path := range paths {
+		rec, err := LoadSessionRecord(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Updated.After(records[j].Updated)
+	})
+	return records, nil
+}
+
+// recordSession adds the session to the registry, or updates its record after another
+// prompt. result may be nil (the session was just created, or the prompt failed).
+// Throwaway sessions aren't recorded; registry errors are only logged.
+func (c *Client) recordSession(sessionID, workDir, agentName, prompt string, opts *AgentOptions, result *AgentResult) {
+	if opts != nil && opts.AutoCleanup {
+		return
+	}
+
+	rec, err := LoadSessionRecord(sessionID)
+	if err != nil {
+		// New session, or one from before the registry
+		rec = &SessionRecord{
+			ID:        sessionID,
+			Tool:      agentName,
+			Summary:   summarize(prompt, summaryLen),
+			WorkDir:   workDir,
+			Branch:    gitBranch(workDir),
+			ServerURL: c.baseURL,
+			Isolated:  os.Getenv("OPENCODE_SDK_ISOLATED") == "1",
+			Created:   time.Now(),
+		}
+		if opts != nil && !opts.NoAgent {
+			rec.Agent = agentName
+		}
+		if opts != nil {
+			rec.System = opts.System
+		}
+		if opts != nil && opts.Tool != "" {
+			rec.Tool = opts.Tool
+		}
+		if opts != nil && opts.Summary != "" {
+			rec.Summary = summarize(opts.Summary, summaryLen)
+		}
+		if c.logger != nil {
+			rec.LogPath = c.logger.Path()
+		}
+		if opts != nil && opts.Fork != nil {
+			rec.ForkedFrom, rec.ForkedAt = opts.Fork.SessionID, opts.Fork.MessageID
+		}
+	}
+
+	if result != nil {
+		rec.Prompts++
+		rec.Updated = time.Now()
+		if result.Model != "" {
+			rec.Model = result.Model
+		}
+		// opencode retitles sessions from the first prompt, so ask for the current title
+		// once the prompt is answered
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if session, err := c.Session.Get(ctx, sessionID, opencode.SessionGetParams{}); err == nil {
+			rec.Title = session.Title
+		}
+		cancel()
+	} else if rec.Updated.IsZero() {
+		rec.Updated = rec.Created
+	}
+
+	if err := SaveSessionRecord(rec); err != nil {
+		c.log("Warning: could not record session: %v", err)
+		return
+	}
+	c.log("Session recorded in %s", sessionRecordPath(sessionID))
+}
+
+// summarize returns the first non-empty line of text, cut to max runes
+func summarize(text string, max int) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > max {
+			return string(runes[:max-1]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// gitBranch returns the checked-out branch of dir ("" outside a git repo)
+func gitBranch(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
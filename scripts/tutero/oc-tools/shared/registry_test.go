@@ -0,0 +1,57 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+func TestRecordSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gets := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/session/ses_1" {
+			http.NotFound(w, r)
+			return
+		}
+		gets++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "ses_1", "title": "Fix the flaky test"}`)
+	}))
+	defer srv.Close()
+	c := &Client{
+		Client: opencode.NewClient(option.WithBaseURL(srv.URL), option.WithMaxRetries(0)),
+		ctx:    context.Background(),
+	}
+	workDir := t.TempDir()
+
+	// Before the prompt: recorded without asking the server
+	c.recordSession("ses_1", workDir, "big-brain", "Why is TestFoo flaky?", &AgentOptions{}, nil)
+	rec, err := LoadSessionRecord("ses_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gets != 0 || rec.Prompts != 0 || rec.Summary != "Why is TestFoo flaky?" || rec.Agent != "big-brain" {
+		t.Errorf("before the prompt: %d title lookups, record %+v", gets, rec)
+	}
+
+	// After it: the title opencode gave the session is fetched once
+	c.recordSession("ses_1", workDir, "big-brain", "Why is TestFoo flaky?", &AgentOptions{}, &AgentResult{Model: "anthropic/claude-opus-4-1"})
+	if rec, err = LoadSessionRecord("ses_1"); err != nil {
+		t.Fatal(err)
+	}
+	if gets != 1 || rec.Title != "Fix the flaky test" || rec.Prompts != 1 || rec.Model != "anthropic/claude-opus-4-1" {
+		t.Errorf("after the prompt: %d title lookups, record %+v", gets, rec)
+	}
+
+	// Throwaway sessions are never recorded
+	c.recordSession("ses_2", workDir, "branch-namer", "name it", &AgentOptions{AutoCleanup: true}, &AgentResult{})
+	if _, err := LoadSessionRecord("ses_2"); err == nil {
+		t.Error("AutoCleanup session was recorded")
+	}
+}
@@ -0,0 +1,286 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Retry defaults (config: max_attempts, retry_backoff)
+const (
+	DefaultMaxAttempts  = 3
+	DefaultRetryBackoff = 2 * time.Second
+	maxRetryBackoff     = time.Minute
+)
+
+// ErrorClass is what kind of failure an agent call hit, deciding whether it is retried
+type ErrorClass int
+
+const (
+	ErrorPermanent         ErrorClass = iota // Anything not listed below; retrying won't help
+	ErrorConnectionRefused                   // Nothing listening (server not up yet, or gone)
+	ErrorServerRestart                       // Connection dropped mid-request (server restarted or crashed)
+	ErrorHTTPStatus                          // HTTP 429 or 5xx from the opencode server
+	ErrorRateLimit                           // The model provider is rate limiting or overloaded
+	ErrorTimeout                             // The run's deadline passed
+	ErrorInvalidAgent                        // The agent doesn't exist
+	ErrorProvider                            // The provider rejected the model (auth, unknown model, ...)
+	ErrorNoToolSupport                       // The model can't make tool calls
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorConnectionRefused:
+		return "connection refused"
+	case ErrorServerRestart:
+		return "server restart"
+	case ErrorHTTPStatus:
+		return "server error"
+	case ErrorRateLimit:
+		return "rate limit"
+	case ErrorTimeout:
+		return "timeout"
+	case ErrorInvalidAgent:
+		return "invalid agent"
+	case ErrorProvider:
+		return "provider error"
+	case ErrorNoToolSupport:
+		return "no tool support"
+	}
+	return "permanent"
+}
+
+// Transient reports whether the failure may go away on its own, so retrying makes sense
+// A timeout is not: the run's time budget is already spent
+func (c ErrorClass) Transient() bool {
+	switch c {
+	case ErrorConnectionRefused, ErrorServerRestart, ErrorHTTPStatus, ErrorRateLimit:
+		return true
+	}
+	return false
+}
+
+// Fallback reports whether another model might succeed where this one failed
+func (c ErrorClass) Fallback() bool {
+	switch c {
+	case ErrorHTTPStatus, ErrorRateLimit, ErrorProvider, ErrorNoToolSupport:
+		return true
+	}
+	return false
+}
+
+// AgentMessageError is an error the server recorded on the assistant message (e.g. the
+// provider rejected the request) while the HTTP call itself succeeded
+type AgentMessageError struct {
+	Name   string // opencode error name, e.g. APIError or ProviderAuthError
+	Detail string // Raw error data from the server
+}
+
+func (e *AgentMessageError) Error() string {
+	return fmt.Sprintf("agent failed with %s: %s", e.Name, e.Detail)
+}
+
+// SentError is a failure after the prompt reached the server, or may have: sending it
+// again would duplicate the user message (and bill the model twice), so it isn't retried
+// and no other model is tried
+type SentError struct {
+	MessageID string // The prompt's user message
+	Err       error  // What the attempt that sent it failed with
+}
+
+func (e *SentError) Error() string { return e.Err.Error() }
+func (e *SentError) Unwrap() error { return e.Err }
+
+// messageError returns the error recorded on msg, or nil (an abort is not an error here,
+// the caller knows it asked for one)
+func messageError(msg opencode.AssistantMessage) error {
+	name := string(msg.Error.Name)
+	if name == "" || msg.Error.Name == opencode.AssistantMessageErrorNameMessageAbortedError {
+		return nil
+	}
+	return &AgentMessageError{Name: name, Detail: msg.Error.JSON.RawJSON()}
+}
+
+// ClassifyError sorts an agent call failure into an ErrorClass
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorPermanent
+	}
+	var interrupted *InterruptedError
+	var exceeded *BudgetExceededError
+	var sent *SentError
+	if errors.As(err, &interrupted) || errors.As(err, &exceeded) || errors.As(err, &sent) {
+		return ErrorPermanent
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorConnectionRefused
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorServerRestart
+	}
+
+	text := strings.ToLower(err.Error())
+	// An exhausted quota or credit balance won't come back by waiting (providers often
+	// send it as a 429), but another provider's model may work
+	if isQuotaExceeded(text) {
+		return ErrorProvider
+	}
+	if isRateLimit(text) {
+		return ErrorRateLimit
+	}
+	if isNoToolSupport(text) {
+		return ErrorNoToolSupport
+	}
+	// Before the agent check: provider errors often say "not found" too
+	if isProviderError(text) {
+		return ErrorProvider
+	}
+	// The server rejects an unknown agent on the request itself; an error recorded on a
+	// message ("agent failed with ...") comes from the provider, not the agent lookup
+	var messageErr *AgentMessageError
+	if !errors.As(err, &messageErr) && agentNotFound.MatchString(text) {
+		return ErrorInvalidAgent
+	}
+
+	var apiErr *opencode.Error
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == 429 || apiErr.StatusCode >= 500) {
+		return ErrorHTTPStatus
+	}
+	if errors.As(err, &messageErr) && strings.Contains(text, `"isretryable":true`) {
+		return ErrorHTTPStatus
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorServerRestart
+	}
+	return ErrorPermanent
+}
+
+// agentNotFound matches opencode's unknown agent errors: "Agent not found: NAME" and
+// "agent NAME not found" / "agent NAME does not exist"
+var agentNotFound = regexp.MustCompile(`\bagent (not found\b|"?[\w.-]+"? (not found|does not exist)\b)`)
+
+// isRateLimit spots provider throttling in an error message
+func isRateLimit(text string) bool {
+	for _, marker := range []string{"rate limit", "rate_limit", "ratelimit", "too many requests", "overloaded"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isQuotaExceeded spots a provider account that is out of quota or credit
+func isQuotaExceeded(text string) bool {
+	for _, marker := range []string{"quota", "credit balance", "insufficient credit", "billing"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNoToolSupport spots a model refusing tool definitions
+func isNoToolSupport(text string) bool {
+	for _, marker := range []string{"does not support tool", "doesn't support tool", "tool use is not supported", "tools are not supported", "tool calling is not supported", "function calling is not supported"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProviderError spots opencode's provider errors (unknown model, failed auth, provider init)
+func isProviderError(text string) bool {
+	for _, marker := range []string{"providerautherror", "providermodelnotfounderror", "provideriniterror", "model not found", "invalid api key", "unauthorized"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy controls how agent calls are retried on transient errors
+type RetryPolicy struct {
+	MaxAttempts int           // Tries per request, including the first (< 1 means 1)
+	Backoff     time.Duration // Wait before the first retry; doubles each retry up to a minute
+}
+
+// DefaultRetryPolicy is used when AgentOptions.Retry is nil
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: DefaultMaxAttempts, Backoff: DefaultRetryBackoff}
+}
+
+// delay returns the wait before retry number attempt (1 = first retry): exponential
+// backoff with jitter, so tools hitting the same rate limit don't retry in lockstep
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	// Somewhere between half and all of the backoff
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// retry runs fn until it succeeds, fails permanently or runs out of attempts, backing
+// off between attempts. Connection failures reconnect to the (possibly restarted) server first.
+func (c *Client) retry(policy *RetryPolicy, op string, fn func() error) error {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				c.log("%s succeeded on attempt %d/%d", op, attempt, attempts)
+			}
+			return nil
+		}
+
+		class := ClassifyError(err)
+		c.log("%s attempt %d/%d failed (%s): %v", op, attempt, attempts, class, err)
+		if !class.Transient() || attempt >= attempts || c.ctx.Err() != nil {
+			if class.Transient() && attempt > 1 {
+				c.log("%s: giving up after %d attempts", op, attempt)
+			}
+			return err
+		}
+
+		wait := policy.delay(attempt)
+		c.log("Retrying %s in %v", op, wait)
+		fmt.Fprintf(os.Stderr, "[retry %d/%d in %v after %s]\n", attempt+1, attempts, wait.Round(100*time.Millisecond), class)
+
+		select {
+		case <-time.After(wait):
+		case <-c.ctx.Done():
+			return err
+		}
+
+		if class == ErrorConnectionRefused || class == ErrorServerRestart {
+			c.reconnect()
+		}
+	}
+}
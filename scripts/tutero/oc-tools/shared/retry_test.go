@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// apiError builds the error the SDK returns for a non-2xx response
+func apiError(t *testing.T, status int, body string) error {
+	t.Helper()
+	err := &opencode.Error{
+		StatusCode: status,
+		Request:    httptest.NewRequest(http.MethodPost, "http://127.0.0.1:4097/session/ses_1/message", nil),
+		Response:   &http.Response{StatusCode: status},
+	}
+	if uerr := err.UnmarshalJSON([]byte(body)); uerr != nil {
+		t.Fatalf("UnmarshalJSON(%s): %v", body, uerr)
+	}
+	return err
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func(t *testing.T) error
+		want ErrorClass
+	}{
+		{"nil", func(*testing.T) error { return nil }, ErrorPermanent},
+		{"deadline", func(*testing.T) error { return fmt.Errorf("prompt: %w", context.DeadlineExceeded) }, ErrorTimeout},
+		{"connection refused", func(*testing.T) error {
+			return fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED)
+		}, ErrorConnectionRefused},
+		{"connection reset", func(*testing.T) error { return fmt.Errorf("read: %w", syscall.ECONNRESET) }, ErrorServerRestart},
+		{"EOF", func(*testing.T) error { return fmt.Errorf("post: %w", io.ErrUnexpectedEOF) }, ErrorServerRestart},
+		{"interrupted", func(*testing.T) error { return &InterruptedError{} }, ErrorPermanent},
+		{"HTTP 503", func(t *testing.T) error { return apiError(t, 503, `{"error":"unavailable"}`) }, ErrorHTTPStatus},
+		{"HTTP 429", func(t *testing.T) error { return apiError(t, 429, `{}`) }, ErrorRateLimit}, // "Too Many Requests"
+		{"HTTP 400", func(t *testing.T) error { return apiError(t, 400, `{"error":"bad request"}`) }, ErrorPermanent},
+		{"unknown agent", func(t *testing.T) error {
+			return apiError(t, 400, `{"name":"UnknownError","data":{"message":"Agent not found: reviewr"}}`)
+		}, ErrorInvalidAgent},
+		{"unknown agent, named first", func(*testing.T) error {
+			return errors.New(`agent "reviewr" not found`)
+		}, ErrorInvalidAgent},
+		{"unknown agent, does not exist", func(*testing.T) error {
+			return errors.New("agent reviewr does not exist")
+		}, ErrorInvalidAgent},
+		{"provider model not found", func(*testing.T) error {
+			return &AgentMessageError{Name: "ProviderModelNotFoundError", Detail: `{"providerID":"openai","modelID":"gpt-9"}`}
+		}, ErrorProvider},
+		{"provider message with not found", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"model not found","statusCode":404}`}
+		}, ErrorProvider},
+		{"message error mentioning not found", func(*testing.T) error {
+			return &AgentMessageError{Name: "UnknownError", Detail: `{"message":"file does not exist"}`}
+		}, ErrorPermanent},
+		{"provider auth", func(*testing.T) error {
+			return &AgentMessageError{Name: "ProviderAuthError", Detail: `{"providerID":"anthropic","message":"invalid api key"}`}
+		}, ErrorProvider},
+		{"retryable message error", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"internal error","statusCode":500,"isRetryable":true}`}
+		}, ErrorHTTPStatus},
+		{"rate limit", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"Rate limit reached for requests"}`}
+		}, ErrorRateLimit},
+		{"overloaded", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"Overloaded"}`}
+		}, ErrorRateLimit},
+		{"quota exhausted", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"You exceeded your current quota","statusCode":429}`}
+		}, ErrorProvider},
+		{"quota as HTTP 429", func(t *testing.T) error {
+			return apiError(t, 429, `{"error":{"type":"insufficient_quota"}}`)
+		}, ErrorProvider},
+		{"credit balance", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"Your credit balance is too low"}`}
+		}, ErrorProvider},
+		{"prompt already sent", func(*testing.T) error {
+			return &SentError{MessageID: "msg_1", Err: fmt.Errorf("post: %w", io.EOF)}
+		}, ErrorPermanent},
+		{"no tool support", func(*testing.T) error {
+			return &AgentMessageError{Name: "APIError", Detail: `{"message":"this model does not support tools"}`}
+		}, ErrorNoToolSupport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err(t)
+			if got := ClassifyError(err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %s, want %s", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorClassRetryAndFallback(t *testing.T) {
+	tests := []struct {
+		class     ErrorClass
+		transient bool
+		fallback  bool
+	}{
+		{ErrorPermanent, false, false},
+		{ErrorConnectionRefused, true, false},
+		{ErrorServerRestart, true, false},
+		{ErrorHTTPStatus, true, true},
+		{ErrorRateLimit, true, true},
+		{ErrorTimeout, false, false},
+		{ErrorInvalidAgent, false, false},
+		{ErrorProvider, false, true},
+		{ErrorNoToolSupport, false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.class.Transient(); got != tt.transient {
+			t.Errorf("%s.Transient() = %v, want %v", tt.class, got, tt.transient)
+		}
+		if got := tt.class.Fallback(); got != tt.fallback {
+			t.Errorf("%s.Fallback() = %v, want %v", tt.class, got, tt.fallback)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 10, Backoff: 2 * time.Second}
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{6, time.Minute}, // 64s, capped
+		{9, time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := p.delay(tt.attempt); d < tt.backoff/2 || d > tt.backoff {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", tt.attempt, d, tt.backoff/2, tt.backoff)
+			}
+		}
+	}
+}
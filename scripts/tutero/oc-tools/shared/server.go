@@ -0,0 +1,381 @@
+package shared
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const ServerStartupTimeout = 30 * time.Second
+
+// Server state layout (one directory per managed port):
+//
+//	~/.cache/scripts/opencode-data/server/<port>/
+//	    lock          flock(2) target, serializes start/stop decisions
+//	    daemon.json   state of the keep-warm daemon owning the server (see daemon.go)
+//	    server.log    stdout/stderr of the managed server (rolled to server.log.1..N)
+//	    leases/<pid>  one file per client process currently using the server
+//
+// Every client registers a lease while holding the lock, so two tools starting at
+// the same time never race on the port. The daemon stops the server once no live
+// lease has been held for its idle timeout. Leases whose process died are treated as
+// expired and pruned; a lease never expires by age, as a run can outlast any fixed
+// limit (timeouts are configurable).
+
+// ServerStateDir returns the lock/lease directory for the managed server on port
+func ServerStateDir(port int) string {
+	return filepath.Join(os.Getenv("HOME"), ".cache", "scripts", "opencode-data", "server", strconv.Itoa(port))
+}
+
+// serverLock is an exclusive flock on the state directory's lock file
+type serverLock struct {
+	file *os.File
+}
+
+// lockServerDir takes the exclusive lock for dir, polling so ctx cancellation is honoured
+func lockServerDir(ctx context.Context, dir string) (*serverLock, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "leases"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create server state dir %s: %w", dir, err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, "lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open server lock: %w", err)
+	}
+
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &serverLock{file: file}, nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock server state: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *serverLock) Unlock() {
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+}
+
+// acquireLease registers this process as a user of the server in dir
+// Caller must hold the server lock
+func acquireLease(dir string) (string, error) {
+	leasePath := filepath.Join(dir, "leases", strconv.Itoa(os.Getpid()))
+	content := fmt.Sprintf("%d %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+	if err := os.WriteFile(leasePath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write lease: %w", err)
+	}
+	return leasePath, nil
+}
+
+// liveLeases prunes expired leases and returns how many are still held
+// Caller must hold the server lock
+func liveLeases(dir string) int {
+	entries, err := os.ReadDir(filepath.Join(dir, "leases"))
+	if err != nil {
+		return 0
+	}
+
+	live := 0
+	for _, entry := range entries {
+		leasePath := filepath.Join(dir, "leases", entry.Name())
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !processAlive(pid) {
+			os.Remove(leasePath)
+			continue
+		}
+		live++
+	}
+	return live
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// startServer spawns `opencode serve` on port and waits for startup message
+// Mimics TS SDK's createOpencodeServer: parses "opencode server listening on http://..."
+// The server runs in its own process group; its stdout and stderr are drained into the
+// rolling server.log in dir for the lifetime of the process. On failure the returned
+// error is a *ServerStartError carrying the last lines of output and an actionable hint.
+func startServer(ctx context.Context, dir string, port int) (*exec.Cmd, string, error) {
+	serverLog, err := openRollingLog(ServerLogPath(dir), ServerLogMaxSize, ServerLogBackups)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open server log: %w", err)
+	}
+	serverLog.WriteLine("daemon", fmt.Sprintf("starting opencode serve on port %d", port))
+
+	cmd := exec.Command("opencode", "serve",
+		fmt.Sprintf("--hostname=%s", LoadConfig().Hostname),
+		fmt.Sprintf("--port=%d", port),
+	)
+	// Also scrubbed here for a daemon started by hand (`big-brain --daemon`) from a run
+	cmd.Env = daemonEnv(os.Environ())
+	// Own process group so stopping the server also stops anything it spawned
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		serverLog.Close()
+		return nil, "", fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		serverLog.Close()
+		return nil, "", fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		serverLog.WriteLine("daemon", err.Error())
+		serverLog.Close()
+		return nil, "", newServerStartError(err, port, nil)
+	}
+
+	// Keep the most recent lines in memory for failure reports
+	recent := newLineRing(ServerLogTailLines)
+	urlRe := regexp.MustCompile(`listening on\s+(https?://[^\s]+)`)
+	ready := make(chan string, 1)
+
+	// Drain both pipes until EOF so the server never blocks on a full pipe buffer
+	var drained sync.WaitGroup
+	drain := func(stream string, r io.Reader, watchURL bool) {
+		defer drained.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		urlSent := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			serverLog.WriteLine(stream, line)
+			recent.Add(line)
+			if watchURL && !urlSent {
+				if matches := urlRe.FindStringSubmatch(line); len(matches) > 1 {
+					ready <- matches[1]
+					urlSent = true
+				}
+			}
+		}
+	}
+	drained.Add(2)
+	go drain("stdout", stdout, true)
+	go drain("stderr", stderr, false)
+
+	exited := make(chan struct{})
+	go func() {
+		// Wait must only run after the pipes are fully read
+		drained.Wait()
+		err := cmd.Wait()
+		serverLog.WriteLine("daemon", fmt.Sprintf("opencode serve exited: %v", err))
+		serverLog.Close()
+		close(exited)
+	}()
+
+	fail := func(err error) (*exec.Cmd, string, error) {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-exited
+		return nil, "", newServerStartError(err, port, recent.Lines())
+	}
+
+	select {
+	case url := <-ready:
+		return cmd, url, nil
+	case <-exited:
+		return fail(fmt.Errorf("server exited before reporting URL"))
+	case <-time.After(ServerStartupTimeout):
+		return fail(errServerStartupTimeout)
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	}
+}
+
+// stopServer terminates the server process group, escalating to SIGKILL after 5 seconds
+func stopServer(pid int) {
+	if !processAlive(pid) {
+		return
+	}
+	syscall.Kill(-pid, syscall.SIGTERM)
+	for i := 0; i < 50 && processAlive(pid); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if processAlive(pid) {
+		syscall.Kill(-pid, syscall.SIGKILL)
+	}
+}
+
+// ensureServer registers a lease and makes sure a server is listening, reusing the
+// keep-warm daemon when one is up and spawning it otherwise
+// The lease is taken before the daemon is spawned so a fresh daemon never sees an
+// idle server, and the state lock serializes spawn decisions between tools
+func (c *Client) ensureServer(port int) error {
+	c.stateDir = ServerStateDir(port)
+
+	lock, err := lockServerDir(c.ctx, c.stateDir)
+	if err != nil {
+		return err
+	}
+
+	lease, err := acquireLease(c.stateDir)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+	c.lease = lease
+	c.log("Lease acquired: %s", lease)
+
+	// Tee server output into our log from here on (includes startup output if we spawn the daemon)
+	if c.logger != nil {
+		var offset int64
+		if info, err := os.Stat(ServerLogPath(c.stateDir)); err == nil {
+			offset = info.Size()
+		}
+		c.stopFollow = make(chan struct{})
+		c.followDone = make(chan struct{})
+		go func() {
+			defer close(c.followDone)
+			c.followServerLog(ServerLogPath(c.stateDir), offset, c.stopFollow)
+		}()
+	}
+
+	state := readDaemonState(c.stateDir)
+	if state != nil && state.alive() && state.URL != "" && probeServer(state.URL) != probeOpencode {
+		// Daemon is up but its server stopped answering - replace it
+		c.log("Daemon (pid %d) not responding at %s, restarting", state.PID, state.URL)
+		syscall.Kill(state.PID, syscall.SIGTERM)
+		state = nil
+	}
+
+	if state != nil && state.alive() {
+		c.log("Daemon already running (pid %d)", state.PID)
+	} else {
+		switch probeServer(c.baseURL) {
+		case probeOpencode:
+			// Someone else's server (e.g. a manual `opencode serve`) - use it as-is
+			lock.Unlock()
+			c.log("Server already running at: %s", c.baseURL)
+			return nil
+		case probeForeign:
+			// The daemon notices too and moves to a free port
+			c.log("Port %d is held by something that is not opencode", port)
+		}
+
+		c.log("Server not running, starting daemon...")
+		state, err = spawnDaemon(c.stateDir, port)
+		if err != nil {
+			lock.Unlock()
+			c.releaseServer()
+			return err
+		}
+		c.log("Daemon spawned (pid %d)", state.PID)
+	}
+	lock.Unlock()
+
+	if state.URL == "" {
+		state, err = waitForDaemon(c.ctx, c.stateDir, state.PID, port)
+		if err != nil {
+			c.releaseServer()
+			return err
+		}
+	}
+
+	c.baseURL = state.URL
+	c.log("Using daemon server at: %s (started %s)", state.URL, state.StartedAt.Format(time.RFC3339))
+	return nil
+}
+
+// releaseServer drops this client's lease; the daemon shuts the server down once idle
+func (c *Client) releaseServer() {
+	if c.stopFollow != nil {
+		close(c.stopFollow)
+		<-c.followDone
+		c.stopFollow = nil
+	}
+	if c.lease == "" {
+		return
+	}
+
+	// Use a fresh context: c.ctx may already be expired when Close runs after a timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lock, err := lockServerDir(ctx, c.stateDir)
+	if err != nil {
+		c.log("Warning: could not lock server state to release lease: %v", err)
+	} else {
+		defer lock.Unlock()
+	}
+
+	os.Remove(c.lease)
+	c.lease = ""
+	c.log("Lease released")
+}
+
+// serverProbe is what probeServer found listening at a URL
+type serverProbe int
+
+const (
+	probeNone     serverProbe = iota // Nothing listening (or not answering HTTP)
+	probeOpencode                    // An opencode server
+	probeForeign                     // Something else answering HTTP
+)
+
+// probeServer checks whether the listener at baseURL really is an opencode server
+// GET /path is cheap and returns opencode's config/state/worktree paths, which other
+// HTTP services on the same port will not produce
+func probeServer(baseURL string) serverProbe {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(baseURL + "/path")
+	if err != nil {
+		return probeNone
+	}
+	defer resp.Body.Close()
+
+	var paths struct {
+		Config   *string `json:"config"`
+		State    *string `json:"state"`
+		Worktree *string `json:"worktree"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK ||
+		json.Unmarshal(body, &paths) != nil ||
+		paths.Config == nil || paths.State == nil || paths.Worktree == nil {
+		return probeForeign
+	}
+	return probeOpencode
+}
+
+// freePort asks the kernel for an unused TCP port on the configured hostname
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(LoadConfig().Hostname, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,45 @@
+package shared
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLiveLeases(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "leases"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := acquireLease(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A run longer than any timeout keeps its lease as long as its process lives
+	old := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(lease, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	dead := filepath.Join(dir, "leases", strconv.Itoa(1<<22+1)) // Above Linux's pid_max
+	junk := filepath.Join(dir, "leases", "not-a-pid")
+	for _, path := range []string{dead, junk} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := liveLeases(dir); got != 1 {
+		t.Errorf("liveLeases() = %d, want 1", got)
+	}
+	if _, err := os.Stat(lease); err != nil {
+		t.Errorf("live lease pruned: %v", err)
+	}
+	for _, path := range []string{dead, junk} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not pruned", filepath.Base(path))
+		}
+	}
+}
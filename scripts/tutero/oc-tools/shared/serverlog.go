@@ -0,0 +1,310 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ServerLogMaxSize   = 5 * 1024 * 1024 // Roll server.log at 5MB
+	ServerLogBackups   = 3               // Keep server.log.1 .. server.log.3
+	ServerLogTailLines = 20              // Lines shown when startup fails
+)
+
+// ServerLogPath returns the rolling `opencode serve` output log in a server state dir
+func ServerLogPath(dir string) string {
+	return filepath.Join(dir, "server.log")
+}
+
+// rollingLog is an append-only line log that rotates to path.1..path.N when it grows past maxSize
+type rollingLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+	backups int
+}
+
+// openRollingLog opens (or creates) path for appending
+func openRollingLog(path string, maxSize int64, backups int) (*rollingLog, error) {
+	r := &rollingLog{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rollingLog) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts path -> path.1 -> ... -> path.N, dropping the oldest
+func (r *rollingLog) rotate() {
+	r.file.Close()
+	r.file = nil
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	os.Rename(r.path, r.path+".1")
+	r.open()
+}
+
+// WriteLine appends a timestamped line tagged with its stream (stdout, stderr, daemon)
+func (r *rollingLog) WriteLine(stream, line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	if r.size >= r.maxSize {
+		r.rotate()
+		if r.file == nil {
+			return
+		}
+	}
+
+	n, _ := fmt.Fprintf(r.file, "[%s] [%s] %s\n", time.Now().Format("2006-01-02 15:04:05.000"), stream, line)
+	r.size += int64(n)
+}
+
+// Close closes the log file
+func (r *rollingLog) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// lineRing keeps the last n lines written to it
+type lineRing struct {
+	mu    sync.Mutex
+	lines []string
+	max   int
+}
+
+func newLineRing(max int) *lineRing {
+	return &lineRing{max: max}
+}
+
+func (r *lineRing) Add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = append(r.lines, line)
+	if len(r.lines) > r.max {
+		r.lines = r.lines[len(r.lines)-r.max:]
+	}
+}
+
+func (r *lineRing) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.lines...)
+}
+
+// tailLines returns the last n lines of the file at path
+func tailLines(path string, n int) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// Startup failure kinds recognised by classifyServerFailure
+const (
+	ServerFailurePortInUse      = "port-in-use"
+	ServerFailureNotInstalled   = "not-installed"
+	ServerFailureConfig         = "config"
+	ServerFailureProvider       = "provider"
+	ServerFailurePermission     = "permission"
+	ServerFailureDiskFull       = "disk-full"
+	ServerFailureStartupTimeout = "startup-timeout"
+)
+
+// ServerStartError describes a failed `opencode serve` startup
+type ServerStartError struct {
+	Err     error
+	Kind    string   // One of the ServerFailure* kinds (empty if unrecognised)
+	Hint    string   // Actionable explanation classified from the output (may be empty)
+	Tail    []string // Last lines of server output
+	LogPath string   // Full server log
+}
+
+func (e *ServerStartError) Error() string {
+	if e.Hint != "" {
+		return fmt.Sprintf("%v: %s", e.Err, e.Hint)
+	}
+	return e.Err.Error()
+}
+
+func (e *ServerStartError) Unwrap() error {
+	return e.Err
+}
+
+// Print writes the hint and the captured server output for the user
+func (e *ServerStartError) Print(w io.Writer) {
+	if e.Hint != "" {
+		fmt.Fprintf(w, "[opencode] %s\n", e.Hint)
+	}
+	if len(e.Tail) > 0 {
+		fmt.Fprintf(w, "[opencode] Last %d lines of server output:\n", len(e.Tail))
+		for _, line := range e.Tail {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	if e.LogPath != "" {
+		fmt.Fprintf(w, "[opencode] Full server log: %s\n", e.LogPath)
+	}
+}
+
+// newServerStartError classifies a startup failure from the error and recent output
+func newServerStartError(err error, port int, tail []string) *ServerStartError {
+	kind, hint := classifyServerFailure(err, port, tail)
+	return &ServerStartError{
+		Err:  err,
+		Kind: kind,
+		Hint: hint,
+		Tail: tail,
+	}
+}
+
+// serverFailurePatterns map substrings of opencode output to actionable messages
+// Checked in order and case-sensitively, against opencode's and the OS's exact error
+// text, so ordinary log chatter ("this port", "API key") doesn't match; {port} is
+// replaced with the server port
+var serverFailurePatterns = []struct {
+	kind    string
+	needles []string
+	hint    string
+}{
+	{
+		ServerFailurePortInUse,
+		[]string{"address already in use", "EADDRINUSE", "Failed to start server. Is port"},
+		"port {port} is already in use by another process - find it with `lsof -i :{port}`, or point the tools at a running server with OPENCODE_URL",
+	},
+	{
+		ServerFailureNotInstalled,
+		[]string{"executable file not found"},
+		"'opencode' is not installed or not in PATH - install it (https://opencode.ai) and retry",
+	},
+	{
+		ServerFailureConfig,
+		[]string{"ConfigJsonError", "ConfigInvalidError", "ConfigDirectoryTypoError", "Config file at", "JSON Parse error"},
+		"opencode config is invalid - check ~/.config/opencode/opencode.json and any project opencode.json for syntax errors or unknown keys",
+	},
+	{
+		ServerFailureProvider,
+		[]string{"ProviderInitError", "ProviderAuthError"},
+		"a provider failed to initialize - check credentials with `opencode auth list` / `opencode auth login`",
+	},
+	{
+		ServerFailurePermission,
+		[]string{"EACCES", "permission denied"},
+		"permission denied - check that the opencode data dir (XDG_DATA_HOME) and binary are accessible",
+	},
+	{
+		ServerFailureDiskFull,
+		[]string{"ENOSPC", "no space left on device"},
+		"disk is full - free space under ~/.cache/scripts/opencode-data",
+	},
+}
+
+// classifyServerFailure turns raw startup failure output into a failure kind and actionable message
+func classifyServerFailure(err error, port int, tail []string) (string, string) {
+	haystack := strings.Join(tail, "\n")
+	if err != nil {
+		haystack += "\n" + err.Error()
+	}
+	for _, p := range serverFailurePatterns {
+		for _, needle := range p.needles {
+			if strings.Contains(haystack, needle) {
+				return p.kind, strings.ReplaceAll(p.hint, "{port}", strconv.Itoa(port))
+			}
+		}
+	}
+
+	if errors.Is(err, errServerStartupTimeout) {
+		return ServerFailureStartupTimeout, fmt.Sprintf("opencode serve did not report a URL within %v - it may be stuck installing plugins or migrating data", ServerStartupTimeout)
+	}
+	return "", ""
+}
+
+var errServerStartupTimeout = errors.New("server startup timeout")
+
+// followServerLog copies lines appended to the server log into the client's Logger until stop closes
+// Starting at offset lets a client that spawned the daemon see the startup output too
+func (c *Client) followServerLog(path string, offset int64, stop <-chan struct{}) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	var partial string
+	readNew := func() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		if info.Size() < offset {
+			// Rotated or truncated - start over on the new file
+			offset = 0
+			partial = ""
+		}
+		if info.Size() == offset {
+			return
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		file.Seek(offset, io.SeekStart)
+		data, _ := io.ReadAll(file)
+		file.Close()
+		offset += int64(len(data))
+
+		lines := strings.Split(partial+string(data), "\n")
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			c.logger.Log("[SERVER] %s", line)
+		}
+	}
+
+	for {
+		readNew()
+		select {
+		case <-stop:
+			readNew()
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyServerFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		tail []string
+		kind string
+		hint string // Substring of the hint
+	}{
+		{
+			name: "port in use",
+			err:  errors.New("exit status 1"),
+			tail: []string{"Error: Failed to start server. Is port 4097 in use?", "EADDRINUSE: address already in use"},
+			kind: ServerFailurePortInUse,
+			hint: "`lsof -i :4097`",
+		},
+		{
+			name: "not installed",
+			err:  fmt.Errorf("start opencode: %w", exec.ErrNotFound),
+			kind: ServerFailureNotInstalled,
+			hint: "not installed",
+		},
+		{
+			name: "config",
+			tail: []string{"ConfigJsonError: Config file at /home/u/.config/opencode/opencode.json is not valid JSON"},
+			kind: ServerFailureConfig,
+			hint: "opencode.json",
+		},
+		{
+			name: "port in use, opencode's message alone",
+			err:  errors.New("exit status 1"),
+			tail: []string{"Error: Failed to start server. Is port 4097 in use?"},
+			kind: ServerFailurePortInUse,
+		},
+		{
+			name: "provider",
+			tail: []string{"ProviderInitError: anthropic"},
+			kind: ServerFailureProvider,
+			hint: "opencode auth login",
+		},
+		{
+			name: "permission",
+			tail: []string{"EACCES: permission denied, open '/data/opencode/storage'"},
+			kind: ServerFailurePermission,
+		},
+		{
+			name: "disk full",
+			tail: []string{"ENOSPC: no space left on device, write"},
+			kind: ServerFailureDiskFull,
+		},
+		{
+			name: "first pattern wins",
+			tail: []string{"EACCES: permission denied", "Error: Failed to start server. Is port 4097 in use?"},
+			kind: ServerFailurePortInUse,
+		},
+		{
+			name: "startup timeout",
+			err:  fmt.Errorf("waiting for opencode: %w", errServerStartupTimeout),
+			tail: []string{"installing plugins"},
+			kind: ServerFailureStartupTimeout,
+			hint: "did not report a URL",
+		},
+		{
+			name: "output explains the timeout",
+			err:  errServerStartupTimeout,
+			tail: []string{"JSON Parse error: Unexpected token"},
+			kind: ServerFailureConfig,
+		},
+		{
+			name: "chatter mentioning a port",
+			err:  errors.New("exit status 1"),
+			tail: []string{"INFO service=server this port is reserved for the TUI"},
+		},
+		{
+			name: "chatter mentioning API keys",
+			err:  errors.New("exit status 1"),
+			tail: []string{"INFO service=provider loading API key from env", "WARN request failed: Unauthorized, retrying"},
+		},
+		{
+			name: "error names are case-sensitive",
+			tail: []string{"providerinitError: anthropic", "eaddrinuse"},
+		},
+		{
+			name: "unrecognised",
+			err:  errors.New("exit status 1"),
+			tail: []string{"panic: something else"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, hint := classifyServerFailure(tt.err, 4097, tt.tail)
+			if kind != tt.kind || !strings.Contains(hint, tt.hint) || (kind == "") != (hint == "") {
+				t.Errorf("classifyServerFailure() = %q, %q; want %q with a hint containing %q", kind, hint, tt.kind, tt.hint)
+			}
+		})
+	}
+}
+
+func TestServerStartError(t *testing.T) {
+	cause := errors.New("exit status 1")
+	err := newServerStartError(cause, 4097, []string{"EADDRINUSE: address already in use"})
+	if !errors.Is(err, cause) || !strings.HasPrefix(err.Error(), "exit status 1: port 4097 is already in use") {
+		t.Errorf("newServerStartError() = %v", err)
+	}
+	if err := newServerStartError(cause, 4097, nil); err.Error() != "exit status 1" {
+		t.Errorf("unrecognised failure = %q, want the cause alone", err.Error())
+	}
+}
+
+func TestRollingLog(t *testing.T) {
+	path := ServerLogPath(t.TempDir())
+	log, err := openRollingLog(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 12; i++ {
+		log.WriteLine("stdout", fmt.Sprintf("line %02d", i))
+	}
+	log.Close()
+	log.WriteLine("stdout", "after close") // Dropped
+
+	// Each line is about 50 bytes, so every third line rotates; only two backups are kept
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s: %v", filepath.Base(name), err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("%s.3 kept, want only 2 backups", filepath.Base(path))
+	}
+
+	tail := tailLines(path, 5)
+	if len(tail) == 0 || !strings.HasSuffix(tail[len(tail)-1], "[stdout] line 11") {
+		t.Errorf("tailLines() = %q, want the last line written", tail)
+	}
+	if tailLines(path+".missing", 5) != nil {
+		t.Error("tailLines() of a missing file, want nil")
+	}
+}
+
+func TestLineRing(t *testing.T) {
+	ring := newLineRing(3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		ring.Add(line)
+	}
+	if got := strings.Join(ring.Lines(), " "); got != "c d e" {
+		t.Errorf("Lines() = %q, want the last 3", got)
+	}
+}
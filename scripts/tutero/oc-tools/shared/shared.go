@@ -4,13 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +18,7 @@ import (
 	"github.com/sst/opencode-sdk-go/option"
 )
 
+// Built-in defaults; users override them in the config file (see config.go)
 const (
 	DefaultHostname = "localhost" // Use localhost, not 127.0.0.1 - avoids Cloudflare tunnel routing issues
 	DefaultPort     = 4096
@@ -40,10 +40,20 @@ func IsolateDataDir() {
 // Client wraps opencode client with server lifecycle management
 type Client struct {
 	*opencode.Client
-	ctx       context.Context
-	serverCmd *exec.Cmd
-	baseURL   string
-	logger    *Logger
+	ctx      context.Context
+	baseURL  string
+	logger   *Logger
+	port     int    // Port of the managed server (unused if OPENCODE_URL is set)
+	stateDir string // Lock/lease directory of the managed server (empty if OPENCODE_URL is set)
+	lease    string // Lease file registered by this client (empty if none)
+
+	stopFollow chan struct{} // Closed to stop teeing server.log into logger
+	followDone chan struct{}
+
+	cleanupMu sync.Mutex
+	throwaway map[string]string // AutoCleanup sessions not deleted yet: session ID -> workDir
+
+	signals *runSignals // Signal handling of the run in progress (nil between runs)
 }
 
 // NewClient creates opencode client, auto-starting server if needed (like TS SDK createOpencode)
@@ -54,48 +64,72 @@ func NewClient(ctx context.Context) *Client {
 // NewClientWithLogger creates opencode client with a logger for realtime activity logging
 func NewClientWithLogger(ctx context.Context, logger *Logger) *Client {
 	baseURL := os.Getenv("OPENCODE_URL")
-	if baseURL == "" {
-		// Use isolated port if IsolateDataDir() was called
-		port := DefaultPort
-		if os.Getenv("OPENCODE_SDK_ISOLATED") == "1" {
-			port = IsolatedPort
-		}
-		baseURL = fmt.Sprintf("http://%s:%d", DefaultHostname, port)
+	external := baseURL != ""
+
+	// Use isolated port if IsolateDataDir() was called
+	cfg := LoadConfig()
+	port := cfg.ServerPort(os.Getenv("OPENCODE_SDK_ISOLATED") == "1")
+	if !external {
+		baseURL = cfg.ServerURL(port)
 	}
 
 	c := &Client{
 		ctx:     ctx,
 		baseURL: baseURL,
+		port:    port,
 		logger:  logger,
 	}
 
 	c.log("Creating client with baseURL: %s", baseURL)
 	c.log("Isolated mode: %v", os.Getenv("OPENCODE_SDK_ISOLATED") == "1")
 
-	// Check if server is already running
-	if !c.isServerRunning() {
-		c.log("Server not running, starting...")
-		// Start server subprocess (mimics TS SDK createOpencodeServer pattern)
-		if url, err := c.startServer(); err != nil {
-			c.log("ERROR: could not start server: %v", err)
+	if external {
+		// Caller manages the server lifecycle
+		c.log("Using OPENCODE_URL, server lifecycle not managed")
+	} else if err := c.ensureServer(port); err != nil {
+		c.log("ERROR: could not start server: %v", err)
+		// Server output itself is already in our log via followServerLog
+		var startErr *ServerStartError
+		if errors.As(err, &startErr) {
+			fmt.Fprintf(os.Stderr, "[opencode] Warning: could not start server: %v\n", startErr.Err)
+			startErr.Print(os.Stderr)
+		} else {
 			fmt.Fprintf(os.Stderr, "[opencode] Warning: could not start server: %v\n", err)
 			fmt.Fprintf(os.Stderr, "[opencode] Ensure 'opencode' is installed and in PATH\n")
-		} else {
-			c.log("Server started at: %s", url)
-			c.baseURL = url
 		}
-	} else {
-		c.log("Server already running at: %s", baseURL)
 	}
 
-	c.Client = opencode.NewClient(
-		option.WithBaseURL(c.baseURL),
-	)
+	c.Client = c.newSDKClient()
 	c.log("SDK client initialized")
 
 	return c
 }
 
+// newSDKClient creates the SDK client for baseURL
+// The SDK's own retries are off: retry (retry.go) classifies errors and backs off itself
+func (c *Client) newSDKClient() *opencode.Client {
+	return opencode.NewClient(
+		option.WithBaseURL(c.baseURL),
+		option.WithMaxRetries(0),
+	)
+}
+
+// reconnect makes sure a server is up again after a connection failure: the keep-warm
+// daemon replaces a server that stopped answering, or a new daemon is spawned
+// Servers we don't manage (OPENCODE_URL) are left alone
+func (c *Client) reconnect() {
+	if c.stateDir == "" {
+		return
+	}
+	c.log("Reconnecting to server...")
+	c.releaseServer()
+	if err := c.ensureServer(c.port); err != nil {
+		c.log("ERROR: could not restart server: %v", err)
+		return
+	}
+	c.Client = c.newSDKClient()
+}
+
 // log writes to the client's logger if available
 func (c *Client) log(format string, args ...interface{}) {
 	if c.logger != nil {
@@ -103,16 +137,15 @@ func (c *Client) log(format string, args ...interface{}) {
 	}
 }
 
-// streamEvents streams SSE events for a session and logs them in real-time
-func (c *Client) streamEvents(ctx context.Context, sessionID, workDir string) {
-	if c.logger == nil {
-		return
-	}
-
-	c.logger.Log("[STREAM] Starting event stream for session %s", sessionID)
+// streamEvents streams SSE events for a session, logs them in real-time, records
+// usage and tool calls in stats and forwards the answer (nil = don't)
+// It runs even without a logger, and closes connected once the stream is open
+func (c *Client) streamEvents(ctx context.Context, sessionID, workDir string, stats *runStats, answer *textStream, connected chan<- struct{}) {
+	c.logEvent("[STREAM] Starting event stream for session %s", sessionID)
 
 	stream := c.Event.ListStreaming(ctx, opencode.EventListParams{})
 	defer stream.Close()
+	close(connected)
 
 	eventCount := 0
 	for stream.Next() {
@@ -127,6 +160,8 @@ func (c *Client) streamEvents(ctx context.Context, sessionID, workDir string) {
 			if part.SessionID != sessionID {
 				continue
 			}
+			stats.observePart(part)
+			answer.update(part)
 
 			// Log based on part type
 			switch part.Type {
@@ -140,29 +175,29 @@ func (c *Client) streamEvents(ctx context.Context, sessionID, workDir string) {
 					text = text[:500] + "..."
 				}
 				if text != "" {
-					c.logger.Log("[TEXT] %s", text)
+					c.logEvent("[TEXT] %s", text)
 				}
 
 			case opencode.PartTypeTool:
 				// State is interface{}, need to extract status
 				if state, ok := part.State.(opencode.ToolPartState); ok {
-					c.logger.Log("[TOOL] %s (status: %s)", part.Tool, state.Status)
+					c.logEvent("[TOOL] %s (status: %s)", part.Tool, state.Status)
 					if state.Input != nil {
 						input := fmt.Sprintf("%v", state.Input)
 						if len(input) > 500 {
 							input = input[:500] + "..."
 						}
-						c.logger.Log("[TOOL INPUT] %s", input)
+						c.logEvent("[TOOL INPUT] %s", input)
 					}
 					if state.Output != "" {
 						output := state.Output
 						if len(output) > 1000 {
 							output = output[:1000] + "..."
 						}
-						c.logger.Log("[TOOL OUTPUT] %s", output)
+						c.logEvent("[TOOL OUTPUT] %s", output)
 					}
 				} else {
-					c.logger.Log("[TOOL] %s", part.Tool)
+					c.logEvent("[TOOL] %s", part.Tool)
 				}
 
 			case opencode.PartTypeReasoning:
@@ -171,124 +206,83 @@ func (c *Client) streamEvents(ctx context.Context, sessionID, workDir string) {
 					text = text[:500] + "..."
 				}
 				if text != "" {
-					c.logger.Log("[THINKING] %s", text)
+					c.logEvent("[THINKING] %s", text)
 				}
 			}
 
 		case opencode.EventListResponseEventMessageUpdated:
 			msg := ev.Properties.Info
-			c.logger.Log("[MESSAGE] Complete (role: %s)", msg.Role)
+			if msg.SessionID != sessionID {
+				continue
+			}
+			// Only the run's own answers count and are streamed, not the prompt itself
+			assistant, ok := msg.AsUnion().(opencode.AssistantMessage)
+			ours := ok && stats.answers(assistant)
+			answer.message(msg.ID, ours)
+			if ours {
+				stats.observeMessage(assistant)
+			}
+			c.logEvent("[MESSAGE] Complete (role: %s)", msg.Role)
 
 		case opencode.EventListResponseEventSessionError:
-			c.logger.Log("[ERROR] %v", ev.Properties.Error)
+			c.logEvent("[ERROR] %v", ev.Properties.Error)
 
 		case opencode.EventListResponseEventSessionUpdated:
 			info := ev.Properties.Info
 			if info.ID == sessionID {
-				c.logger.Log("[SESSION] Updated: %s", info.Title)
+				c.logEvent("[SESSION] Updated: %s", info.Title)
 			}
 
 		case opencode.EventListResponseEventSessionIdle:
-			c.logger.Log("[SESSION] Idle")
+			c.logEvent("[SESSION] Idle")
 		}
 	}
 
 	if err := stream.Err(); err != nil && ctx.Err() == nil {
-		c.logger.Log("[STREAM] Error: %v", err)
+		c.logEvent("[STREAM] Error: %v", err)
 	}
-	c.logger.Log("[STREAM] Event stream ended (processed %d events)", eventCount)
+	c.logEvent("[STREAM] Event stream ended (processed %d events)", eventCount)
 }
 
-// isServerRunning checks if opencode server is available
-func (c *Client) isServerRunning() bool {
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(c.baseURL + "/session")
-	if err != nil {
-		return false
+// logEvent writes a stream event to the client's logger if available
+func (c *Client) logEvent(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Log(format, args...)
 	}
-	resp.Body.Close()
-	return resp.StatusCode == 200
 }
 
-// startServer spawns `opencode serve` and waits for startup message
-// Mimics TS SDK's createOpencodeServer: parses "opencode server listening on http://..."
-func (c *Client) startServer() (string, error) {
-	// Use isolated port if set
-	port := DefaultPort
-	if os.Getenv("OPENCODE_SDK_ISOLATED") == "1" {
-		port = IsolatedPort
-	}
-
-	cmd := exec.Command("opencode", "serve",
-		fmt.Sprintf("--hostname=%s", DefaultHostname),
-		fmt.Sprintf("--port=%d", port),
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-
-	// Discard stderr to prevent blocking (opencode logs go to stderr with --print-logs)
-	cmd.Stderr = nil // goes to os.DevNull
-
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start opencode serve: %w", err)
-	}
-
-	c.serverCmd = cmd
-
-	// Parse stdout for: "opencode server listening on http://127.0.0.1:4096"
-	urlRe := regexp.MustCompile(`listening on\s+(https?://[^\s]+)`)
-	ready := make(chan string, 1)
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		urlSent := false
-		for scanner.Scan() {
-			line := scanner.Text()
-			if !urlSent {
-				if matches := urlRe.FindStringSubmatch(line); len(matches) > 1 {
-					ready <- matches[1]
-					urlSent = true
-					// Continue draining stdout to prevent pipe buffer from filling
-				}
-			}
-			// Keep consuming stdout until EOF to prevent process blocking
-		}
-		if !urlSent {
-			close(ready)
-		}
-	}()
+// isServerRunning checks if an opencode server (and not some other program) is listening at baseURL
+func (c *Client) isServerRunning() bool {
+	return probeServer(c.baseURL) == probeOpencode
+}
 
-	// Wait up to 30 seconds for server ready
-	select {
-	case url := <-ready:
-		if url != "" {
-			return url, nil
-		}
-		return "", fmt.Errorf("server did not report URL")
-	case <-time.After(30 * time.Second):
-		cmd.Process.Kill()
-		return "", fmt.Errorf("server startup timeout")
-	case <-c.ctx.Done():
-		cmd.Process.Kill()
-		return "", c.ctx.Err()
-	}
+// BaseURL returns the URL of the server this client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
 }
 
-// Close stops the server if we started it
+// Close deletes any throwaway session still around and releases this client's server
+// lease; the daemon stops the server once it has been idle
 func (c *Client) Close() {
-	if c.serverCmd != nil && c.serverCmd.Process != nil {
-		c.serverCmd.Process.Kill()
-		c.serverCmd.Wait()
-	}
+	c.cleanupSessions(true)
+	c.releaseServer()
 }
 
-// AgentResult contains the output and session info for follow-up
+// AgentResult contains the output and session info for follow-up, plus what the run
+// cost; tools print it as-is with --json
 type AgentResult struct {
-	Output    string
-	SessionID string
+	Output      string     `json:"output"`
+	SessionID   string     `json:"session_id"`
+	Agent       string     `json:"agent,omitempty"` // Empty when run without an agent (NoAgent)
+	Model       string     `json:"model,omitempty"` // provider/model that answered
+	Elapsed     Duration   `json:"elapsed"`
+	Usage       Usage      `json:"usage"`
+	Cost        float64    `json:"cost"` // USD, as reported by the provider
+	ToolCalls   []ToolCall `json:"tool_calls"`
+	LogPath     string     `json:"log_path,omitempty"`     // Set by the Tool runner
+	Partial     bool       `json:"partial,omitempty"`      // The prompt failed or timed out; Output is what came before
+	ModelsTried []string   `json:"models_tried,omitempty"` // Models that failed before Model answered
+	Error       string     `json:"error,omitempty"`        // Why the run failed (a partial or failed result)
 }
 
 // AgentOptions contains optional settings for agent calls
@@ -297,7 +291,48 @@ type AgentOptions struct {
 	Model       *ModelConfig    // Override model (provider + model ID)
 	System      string          // System prompt (use instead of agent if set)
 	NoAgent     bool            // If true, don't use agent field (use System instead)
-	AutoCleanup bool            // If true, delete session after completion (prevents history pollution)
+	AutoCleanup bool            // Delete the new session when the run ends, however it ends (prevents history pollution)
+	Quiet       bool            // If true, don't print session banners to stderr
+	ParentID    string          // Create the session as a child of this one (e.g. the calling tool's)
+	Tool        string          // Command recorded in the session registry (defaults to the agent name)
+	Summary     string          // What the registry shows for the session (defaults to the prompt)
+	Fork        *ForkPoint      // Start from a copy of this session's history instead of an empty session (ParentID is ignored)
+
+	// Stream receives the answer as it is generated, ending with a newline; the text is
+	// the same as AgentResult.Output. StreamReasoning also gets reasoning parts.
+	Stream          io.Writer
+	StreamReasoning io.Writer
+
+	Retry *RetryPolicy // Retries of transient failures (nil = DefaultRetryPolicy)
+
+	// Fallbacks are tried in order, in the same session, when the model fails with a
+	// provider error, a rate limit or missing tool-call support. ModelTools adjusts the
+	// tools for each model tried (e.g. websearch only works on the opencode provider).
+	Fallbacks  []*ModelConfig
+	ModelTools func(model *ModelConfig) map[string]bool
+
+	Attachments []*Attachment // Files sent with the prompt (see LoadAttachment)
+
+	// Budget caps the run's cost, output tokens, tool calls and wall time, across retries
+	// and fallbacks. Going over aborts the session; the output so far comes back as a
+	// PartialError wrapping a BudgetExceededError.
+	Budget *Budget
+}
+
+// modelChain returns the models to try: Model (nil = the agent's own), then Fallbacks
+func (opts *AgentOptions) modelChain() []*ModelConfig {
+	if opts == nil {
+		return []*ModelConfig{nil}
+	}
+	return append([]*ModelConfig{opts.Model}, opts.Fallbacks...)
+}
+
+// retryPolicy returns opts.Retry or the default (opts may be nil)
+func (opts *AgentOptions) retryPolicy() *RetryPolicy {
+	if opts == nil || opts.Retry == nil {
+		return DefaultRetryPolicy()
+	}
+	return opts.Retry
 }
 
 // ModelConfig specifies provider and model
@@ -306,6 +341,11 @@ type ModelConfig struct {
 	ModelID    string
 }
 
+// String returns the model as provider/model
+func (m *ModelConfig) String() string {
+	return m.ProviderID + "/" + m.ModelID
+}
+
 // FreeModel represents a free model from opencode provider
 type FreeModel struct {
 	ProviderID  string
@@ -315,60 +355,172 @@ type FreeModel struct {
 	ToolCall    bool
 }
 
-// FindBestFreeModel queries opencode provider for the best free model
-// Returns nil if no free models available
+// FindBestFreeModel returns the most recent free opencode model that can call tools
+// (FreeModelPolicy). Returns nil if no free models available
 func (c *Client) FindBestFreeModel() (*FreeModel, error) {
-	resp, err := c.App.Providers(c.ctx, opencode.AppProvidersParams{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get providers: %w", err)
+	ranked, err := c.SelectModels(FreeModelPolicy())
+	if err != nil || len(ranked) == 0 {
+		return nil, err
 	}
+	best := ranked[0]
+	return &FreeModel{
+		ProviderID:  best.ProviderID,
+		ModelID:     best.ModelID,
+		Name:        best.Name,
+		ReleaseDate: best.ReleaseDate,
+		ToolCall:    best.ToolCall,
+	}, nil
+}
 
-	var bestModel *FreeModel
+// RunAgent creates a session, sends prompt to agent, extracts text output
+// Returns session ID for potential follow-up (session is NOT deleted)
+func (c *Client) RunAgent(agentName, prompt, workDir string) (*AgentResult, error) {
+	return c.RunAgentWithOptions(agentName, prompt, workDir, nil)
+}
 
-	for _, provider := range resp.Providers {
-		if provider.ID != "opencode" {
-			continue
-		}
+// RunAgentWithOptions creates a session with optional settings (tools, etc.)
+func (c *Client) RunAgentWithOptions(agentName, prompt, workDir string, opts *AgentOptions) (result *AgentResult, err error) {
+	c.log("RunAgent called: agent=%s, workDir=%s", agentName, workDir)
+	c.logPrompt(prompt, opts)
 
-		for modelID, model := range provider.Models {
-			// Check if free (zero cost)
-			if model.Cost.Input != 0 || model.Cost.Output != 0 {
-				continue
-			}
+	// Ctrl-C stops the run cleanly anywhere from session creation through cleanup
+	signals := c.watchSignals()
+	var sessionID string
+	defer func() {
+		err = interrupted(err, signals.stop(), sessionID)
+	}()
 
-			// Skip only if tool_call is explicitly false
-			// null or true means tool calls are supported
-			if model.ToolCall == false && model.JSON.ToolCall.IsNull() == false {
-				continue
-			}
+	var session *opencode.Session
+	if opts != nil && opts.Fork != nil {
+		c.log("Forking session %s...", opts.Fork)
+		err = c.retry(opts.retryPolicy(), "fork session", func() error {
+			var err error
+			session, err = c.ForkSession(opts.Fork, workDir)
+			return err
+		})
+		if err != nil {
+			c.log("ERROR: failed to fork session: %v", err)
+			return nil, fmt.Errorf("failed to fork session %s: %w", opts.Fork, err)
+		}
+		c.log("Session forked: %s (from %s)", session.ID, opts.Fork)
+	} else {
+		// Create session
+		c.log("Creating new session...")
+		newParams := opencode.SessionNewParams{
+			Directory: opencode.F(workDir),
+			Title:     opencode.F(fmt.Sprintf("%s-%d", agentName, time.Now().Unix())),
+		}
+		if opts != nil && opts.ParentID != "" {
+			newParams.ParentID = opencode.F(opts.ParentID)
+		}
+		err = c.retry(opts.retryPolicy(), "create session", func() error {
+			var err error
+			session, err = c.Session.New(c.ctx, newParams)
+			return err
+		})
+		if err != nil {
+			c.log("ERROR: failed to create session: %v", err)
+			return nil, fmt.Errorf("failed to create session: %w", err)
+		}
+		c.log("Session created: %s", session.ID)
+	}
+	sessionID = session.ID
+	signals.session(sessionID, workDir)
 
-			candidate := &FreeModel{
-				ProviderID:  "opencode",
-				ModelID:     modelID,
-				Name:        model.Name,
-				ReleaseDate: model.ReleaseDate,
-				ToolCall:    model.ToolCall,
-			}
+	// Throwaway sessions (e.g. branch-namer, --ephemeral) are deleted again once the
+	// prompt returns, however it ends
+	if opts != nil && opts.AutoCleanup {
+		c.deleteLater(sessionID, workDir)
+	}
 
-			// Pick the one with latest release date
-			if bestModel == nil || candidate.ReleaseDate > bestModel.ReleaseDate {
-				bestModel = candidate
-			}
+	// Broadcast session ID early for timeout recovery (a throwaway one can't be continued)
+	if opts == nil || (!opts.Quiet && !opts.AutoCleanup) {
+		fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
+		fmt.Fprintf(os.Stderr, "Session started: %s\n", sessionID)
+		if opts != nil && opts.Fork != nil {
+			fmt.Fprintf(os.Stderr, "Forked from: %s\n", opts.Fork)
 		}
+		fmt.Fprintf(os.Stderr, "If timeout occurs, continue with: -s %s\n", sessionID)
+		fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n\n")
 	}
 
-	return bestModel, nil
+	// Registered before prompting so an interrupted session can still be found
+	c.recordSession(sessionID, workDir, agentName, prompt, opts, nil)
+
+	result, err = c.sendPrompt(sessionID, workDir, promptParams(agentName, prompt, workDir, opts), opts)
+	c.recordSession(sessionID, workDir, agentName, prompt, opts, answered(result, err))
+	c.cleanupSessions(err != nil)
+	return result, err
 }
 
-// RunAgent creates a session, sends prompt to agent, extracts text output
-// Returns session ID for potential follow-up (session is NOT deleted)
-func (c *Client) RunAgent(agentName, prompt, workDir string) (*AgentResult, error) {
-	return c.RunAgentWithOptions(agentName, prompt, workDir, nil)
+// ContinueSession sends a follow-up prompt to an existing session
+func (c *Client) ContinueSession(sessionID, agentName, prompt, workDir string) (*AgentResult, error) {
+	return c.ContinueSessionWithOptions(sessionID, agentName, prompt, workDir, nil)
 }
 
-// RunAgentWithOptions creates a session with optional settings (tools, etc.)
-func (c *Client) RunAgentWithOptions(agentName, prompt, workDir string, opts *AgentOptions) (*AgentResult, error) {
-	c.log("RunAgent called: agent=%s, workDir=%s", agentName, workDir)
+// ContinueSessionWithOptions sends a follow-up prompt with optional settings
+func (c *Client) ContinueSessionWithOptions(sessionID, agentName, prompt, workDir string, opts *AgentOptions) (result *AgentResult, err error) {
+	c.log("ContinueSession called: sessionID=%s, agent=%s, workDir=%s", sessionID, agentName, workDir)
+	c.logPrompt(prompt, opts)
+
+	signals := c.watchSignals()
+	signals.session(sessionID, workDir)
+	defer func() {
+		err = interrupted(err, signals.stop(), sessionID)
+	}()
+
+	// Verify session exists
+	c.log("Verifying session exists...")
+	err = c.retry(opts.retryPolicy(), "get session", func() error {
+		_, err := c.Session.Get(c.ctx, sessionID, opencode.SessionGetParams{})
+		return err
+	})
+	if err != nil {
+		c.log("ERROR: session not found: %v", err)
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	c.log("Session verified")
+
+	if opts == nil || !opts.Quiet {
+		fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
+		fmt.Fprintf(os.Stderr, "Continuing session: %s\n", sessionID)
+		fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n\n")
+	}
+
+	result, err = c.sendPrompt(sessionID, workDir, promptParams(agentName, prompt, workDir, opts), opts)
+	c.recordSession(sessionID, workDir, agentName, prompt, opts, answered(result, err))
+	return result, err
+}
+
+// answered returns what a prompt produced, counting partial output (nil if nothing)
+func answered(result *AgentResult, err error) *AgentResult {
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		return partial.Result
+	}
+	return result
+}
+
+// DeleteSession removes a session from the server (used for throwaway sessions)
+// Uses its own short deadline so cleanup still works after the run's context expired
+func (c *Client) DeleteSession(sessionID, workDir string) error {
+	c.log("Cleaning up session: %s", sessionID)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.Session.Delete(ctx, sessionID, opencode.SessionDeleteParams{
+		Directory: opencode.F(workDir),
+	})
+	if err != nil {
+		c.log("Session cleanup error: %v", err)
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	c.log("Session cleaned up successfully")
+	return nil
+}
+
+// logPrompt records the prompt and options of an agent call
+func (c *Client) logPrompt(prompt string, opts *AgentOptions) {
 	c.log("Prompt length: %d chars", len(prompt))
 	if len(prompt) < 2000 {
 		c.log("Prompt content:\n%s", prompt)
@@ -377,7 +529,7 @@ func (c *Client) RunAgentWithOptions(agentName, prompt, workDir string, opts *Ag
 	}
 
 	if opts != nil {
-		c.log("Options: Tools=%v, NoAgent=%v, AutoCleanup=%v", opts.Tools, opts.NoAgent, opts.AutoCleanup)
+		c.log("Options: Tools=%v, NoAgent=%v, AutoCleanup=%v, ParentID=%q", opts.Tools, opts.NoAgent, opts.AutoCleanup, opts.ParentID)
 		if opts.Model != nil {
 			c.log("Model override: %s/%s", opts.Model.ProviderID, opts.Model.ModelID)
 		}
@@ -385,36 +537,24 @@ func (c *Client) RunAgentWithOptions(agentName, prompt, workDir string, opts *Ag
 			c.log("System prompt length: %d", len(opts.System))
 		}
 	}
+}
 
-	// Create session
-	c.log("Creating new session...")
-	session, err := c.Session.New(c.ctx, opencode.SessionNewParams{
-		Directory: opencode.F(workDir),
-		Title:     opencode.F(fmt.Sprintf("%s-%d", agentName, time.Now().Unix())),
-	})
-	if err != nil {
-		c.log("ERROR: failed to create session: %v", err)
-		return nil, fmt.Errorf("failed to create session: %w", err)
+// promptParams builds the prompt request shared by new and continued sessions
+func promptParams(agentName, prompt, workDir string, opts *AgentOptions) opencode.SessionPromptParams {
+	parts := []opencode.SessionPromptParamsPartUnion{
+		opencode.TextPartInputParam{
+			Type: opencode.F(opencode.TextPartInputTypeText),
+			Text: opencode.F(prompt),
+		},
+	}
+	if opts != nil {
+		for _, attachment := range opts.Attachments {
+			parts = append(parts, attachment.part())
+		}
 	}
-
-	sessionID := session.ID
-	c.log("Session created: %s", sessionID)
-
-	// Broadcast session ID early for timeout recovery
-	fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
-	fmt.Fprintf(os.Stderr, "Session started: %s\n", sessionID)
-	fmt.Fprintf(os.Stderr, "If timeout occurs, continue with: -s %s\n", sessionID)
-	fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n\n")
-
-	// Build prompt params
 	params := opencode.SessionPromptParams{
 		Directory: opencode.F(workDir),
-		Parts: opencode.F([]opencode.SessionPromptParamsPartUnion{
-			opencode.TextPartInputParam{
-				Type: opencode.F(opencode.TextPartInputTypeText),
-				Text: opencode.F(prompt),
-			},
-		}),
+		Parts:     opencode.F(parts),
 	}
 
 	// Use agent unless NoAgent is set
@@ -440,143 +580,230 @@ func (c *Client) RunAgentWithOptions(agentName, prompt, workDir string, opts *Ag
 		params.System = opencode.F(opts.System)
 	}
 
-	// Start streaming events in background for real-time logging
-	c.log("Sending prompt to session...")
-	startTime := time.Now()
-
-	// Create cancellable context for event streaming
-	streamCtx, cancelStream := context.WithCancel(c.ctx)
-	streamDone := make(chan struct{})
-
-	// Stream events in background
-	go func() {
-		defer close(streamDone)
-		c.streamEvents(streamCtx, sessionID, workDir)
-	}()
-
-	// Send prompt (blocking)
-	response, err := c.Session.Prompt(c.ctx, sessionID, params)
-	elapsed := time.Since(startTime)
-
-	// Stop event streaming
-	cancelStream()
-	<-streamDone
+	return params
+}
 
-	if err != nil {
-		c.log("ERROR: prompt failed after %v: %v", elapsed, err)
-		return nil, fmt.Errorf("failed to send prompt: %w", err)
+// sendPrompt sends params to the session while streaming its events into the log
+// (and the answer to opts.Stream, if set), retrying transient failures per opts.Retry and
+// moving down the model chain when a model can't serve the prompt
+func (c *Client) sendPrompt(sessionID, workDir string, params opencode.SessionPromptParams, opts *AgentOptions) (*AgentResult, error) {
+	startTime := time.Now()
+	stats := newRunStats() // Shared by all attempts so failed ones still count towards usage
+	stream := newTextStream(opts)
+	chain := opts.modelChain()
+	var budget *Budget
+	if opts != nil {
+		budget = opts.Budget
 	}
-
-	c.log("Prompt completed in %v", elapsed)
-	c.log("Response parts count: %d", len(response.Parts))
-
-	output := ExtractTextFromParts(response.Parts)
-	c.log("Extracted text length: %d chars", len(output))
-	if len(output) < 5000 {
-		c.log("Response output:\n%s", output)
-	} else {
-		c.log("Response output (first 5000 chars):\n%s...", output[:5000])
+	if !budget.IsZero() {
+		c.log("Budget: %s", budget)
 	}
 
-	return &AgentResult{
-		Output:    output,
-		SessionID: sessionID,
-	}, nil
-}
+	var tried []string
+	var sent string   // User message ID of the last failed attempt's prompt
+	var lastErr error // What that attempt failed with
+	for i, model := range chain {
+		if model != nil {
+			params.Model = opencode.F(opencode.SessionPromptParamsModel{
+				ProviderID: opencode.F(model.ProviderID),
+				ModelID:    opencode.F(model.ModelID),
+			})
+			if opts.ModelTools != nil {
+				params.Tools = opencode.F(opts.ModelTools(model))
+			}
+		}
 
-// ContinueSession sends a follow-up prompt to an existing session
-func (c *Client) ContinueSession(sessionID, agentName, prompt, workDir string) (*AgentResult, error) {
-	return c.ContinueSessionWithOptions(sessionID, agentName, prompt, workDir, nil)
-}
+		var result *AgentResult
+		err := c.retry(opts.retryPolicy(), "prompt", func() error {
+			if sent != "" {
+				if err := c.unsend(sessionID, workDir, sent, lastErr); err != nil {
+					return err
+				}
+			}
+			var err error
+			sent = newMessageID()
+			result, err = c.promptOnce(sessionID, workDir, sent, params, stream, stats, budget, startTime)
+			lastErr = err
+			return err
+		})
+		if err == nil {
+			result.ModelsTried = tried
+			if len(tried) > 0 {
+				c.log("Answered by fallback model %s after %s failed", result.Model, strings.Join(tried, ", "))
+			}
+			return result, nil
+		}
 
-// ContinueSessionWithOptions sends a follow-up prompt with optional settings
-func (c *Client) ContinueSessionWithOptions(sessionID, agentName, prompt, workDir string, opts *AgentOptions) (*AgentResult, error) {
-	c.log("ContinueSession called: sessionID=%s, agent=%s, workDir=%s", sessionID, agentName, workDir)
-	c.log("Prompt length: %d chars", len(prompt))
-	if len(prompt) < 2000 {
-		c.log("Prompt content:\n%s", prompt)
-	} else {
-		c.log("Prompt content (first 2000 chars):\n%s...", prompt[:2000])
+		class := ClassifyError(err)
+		if i == len(chain)-1 || !class.Fallback() || c.ctx.Err() != nil {
+			return nil, usageError(err, stats, sessionID, params, startTime)
+		}
+		tried = append(tried, modelName(model))
+		c.log("Model %s failed (%s), falling back to %s", modelName(model), class, modelName(chain[i+1]))
+		fmt.Fprintf(os.Stderr, "[model %s failed (%s), falling back to %s]\n", modelName(model), class, modelName(chain[i+1]))
 	}
+	return nil, fmt.Errorf("no model to try")
+}
 
-	// Verify session exists
-	c.log("Verifying session exists...")
-	_, err := c.Session.Get(c.ctx, sessionID, opencode.SessionGetParams{})
-	if err != nil {
-		c.log("ERROR: session not found: %v", err)
-		return nil, fmt.Errorf("session not found: %w", err)
+// usageError attaches what a failed run used to err, unless it is a PartialError (which
+// carries its own result)
+func usageError(err error, stats *runStats, sessionID string, params opencode.SessionPromptParams, startTime time.Time) error {
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		return err
 	}
-	c.log("Session verified")
-
-	fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
-	fmt.Fprintf(os.Stderr, "Continuing session: %s\n", sessionID)
-	fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n\n")
-
-	// Build prompt params
-	params := opencode.SessionPromptParams{
-		Directory: opencode.F(workDir),
-		Parts: opencode.F([]opencode.SessionPromptParamsPartUnion{
-			opencode.TextPartInputParam{
-				Type: opencode.F(opencode.TextPartInputTypeText),
-				Text: opencode.F(prompt),
-			},
-		}),
+	result := &AgentResult{
+		SessionID: sessionID,
+		Elapsed:   Duration(time.Since(startTime).Round(time.Millisecond)),
+		Error:     err.Error(),
 	}
-
-	// Use agent unless NoAgent is set
-	if opts == nil || !opts.NoAgent {
-		params.Agent = opencode.F(agentName)
+	if params.Agent.Present {
+		result.Agent = params.Agent.Value
 	}
+	stats.apply(result)
+	return &UsageError{Result: result, Err: err}
+}
 
-	// Add tools if specified
-	if opts != nil && opts.Tools != nil {
-		params.Tools = opencode.F(opts.Tools)
+// unsend makes a failed prompt safe to send again, or returns a SentError if it isn't:
+// a prompt the server never saved is fine as it is, one it answered with an error is
+// reverted (so the next one replaces it), and one it saved without answering may still
+// be running, so it stays. Runs after the retry reconnected to a restarted server.
+func (c *Client) unsend(sessionID, workDir, messageID string, failure error) error {
+	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+
+	var messageErr *AgentMessageError
+	if errors.As(failure, &messageErr) {
+		_, err := c.Session.Revert(ctx, sessionID, opencode.SessionRevertParams{
+			MessageID: opencode.F(messageID),
+			Directory: opencode.F(workDir),
+		})
+		if err != nil {
+			c.log("Could not revert prompt %s, not sending it again: %v", messageID, err)
+			return &SentError{MessageID: messageID, Err: failure}
+		}
+		c.log("Reverted failed prompt %s before sending it again", messageID)
+		return nil
 	}
 
-	// Add model override if specified
-	if opts != nil && opts.Model != nil {
-		params.Model = opencode.F(opencode.SessionPromptParamsModel{
-			ProviderID: opencode.F(opts.Model.ProviderID),
-			ModelID:    opencode.F(opts.Model.ModelID),
-		})
+	_, err := c.Session.Message(ctx, sessionID, messageID, opencode.SessionMessageParams{
+		Directory: opencode.F(workDir),
+	})
+	if IsNotFound(err) {
+		c.log("Prompt %s never reached the session, sending it again", messageID)
+		return nil
+	}
+	if err == nil {
+		c.log("Prompt %s reached the session, not sending it again", messageID)
+	} else {
+		c.log("Could not tell whether prompt %s reached the session, not sending it again: %v", messageID, err)
 	}
+	return &SentError{MessageID: messageID, Err: failure}
+}
 
-	// Add system prompt if specified
-	if opts != nil && opts.System != "" {
-		params.System = opencode.F(opts.System)
+// modelName returns the model as provider/model, or "agent default" for nil
+func modelName(model *ModelConfig) string {
+	if model == nil {
+		return "agent default"
 	}
+	return model.String()
+}
 
+// promptOnce makes one attempt at sending params to the session, as user message messageID
+func (c *Client) promptOnce(sessionID, workDir, messageID string, params opencode.SessionPromptParams, stream *textStream, stats *runStats, budget *Budget, startTime time.Time) (*AgentResult, error) {
 	// Start streaming events in background for real-time logging
 	c.log("Sending prompt to session...")
-	startTime := time.Now()
 
 	// Create cancellable context for event streaming
 	streamCtx, cancelStream := context.WithCancel(c.ctx)
 	streamDone := make(chan struct{})
+	connected := make(chan struct{})
 
 	// Stream events in background
 	go func() {
 		defer close(streamDone)
-		c.streamEvents(streamCtx, sessionID, workDir)
+		c.streamEvents(streamCtx, sessionID, workDir, stats, stream, connected)
 	}()
 
-	// Send prompt (blocking)
-	response, err := c.Session.Prompt(c.ctx, sessionID, params)
+	// Subscribe before prompting so the first events aren't lost (don't wait on a stuck stream)
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+	}
+
+	// Send prompt (blocking); going over budget aborts it on the server rather than
+	// leaving it running, as Ctrl-C does (see watchSignals)
+	promptCtx, cancelPrompt := context.WithCancel(c.ctx)
+	defer cancelPrompt()
+	params.MessageID = opencode.F(messageID)
+	stats.addPrompt(messageID)
+	c.log("Prompt message: %s", messageID)
+	stopBudget := c.enforceBudget(budget, sessionID, workDir, stats, startTime, cancelPrompt)
+	response, err := c.Session.Prompt(promptCtx, sessionID, params)
 	elapsed := time.Since(startTime)
+	sig := c.signals.signal()
+	exceeded := stopBudget()
+	if err == nil && ExtractTextFromParts(response.Parts) == "" {
+		// The request went through but the provider failed (rate limit, auth, ...)
+		if msgErr := messageError(response.Info); msgErr != nil {
+			stats.observeMessage(response.Info)
+			err = msgErr
+		}
+	}
 
 	// Stop event streaming
 	cancelStream()
 	<-streamDone
 
+	if sig != nil && err != nil {
+		c.log("Prompt interrupted by %v after %v", sig, elapsed)
+		return nil, &InterruptedError{SessionID: sessionID, Signal: sig}
+	}
+	if exceeded != nil {
+		// The aborted prompt may have returned normally, with the text cut short
+		err = exceeded
+	}
 	if err != nil {
 		c.log("ERROR: prompt failed after %v: %v", elapsed, err)
-		return nil, fmt.Errorf("failed to send prompt: %w", err)
+		switch {
+		case exceeded != nil:
+		case errors.Is(err, context.DeadlineExceeded):
+			err = fmt.Errorf("timed out after %v: %w", elapsed.Round(time.Second), err)
+		default:
+			err = fmt.Errorf("failed to send prompt: %w", err)
+		}
+
+		// Whatever the agent wrote before it failed is better than nothing
+		parts := c.answerParts(sessionID, workDir, stats)
+		output := ExtractTextFromParts(parts)
+		if output == "" {
+			return nil, err
+		}
+		stream.finish(parts)
+		result := &AgentResult{
+			Output:    output,
+			SessionID: sessionID,
+			Elapsed:   Duration(elapsed.Round(time.Millisecond)),
+			Partial:   true,
+			Error:     err.Error(),
+		}
+		if params.Agent.Present {
+			result.Agent = params.Agent.Value
+		}
+		stats.apply(result)
+		return nil, &PartialError{Result: result, Err: err}
 	}
 
 	c.log("Prompt completed in %v", elapsed)
 	c.log("Response parts count: %d", len(response.Parts))
 
-	output := ExtractTextFromParts(response.Parts)
+	// The response only holds the last message; a multi-step run answers in several
+	parts := c.answerParts(sessionID, workDir, stats)
+	if parts == nil {
+		parts = response.Parts
+	}
+	output := ExtractTextFromParts(parts)
+	stream.finish(parts)
 	c.log("Extracted text length: %d chars", len(output))
 	if len(output) < 5000 {
 		c.log("Response output:\n%s", output)
@@ -584,10 +811,25 @@ func (c *Client) ContinueSessionWithOptions(sessionID, agentName, prompt, workDi
 		c.log("Response output (first 5000 chars):\n%s...", output[:5000])
 	}
 
-	return &AgentResult{
+	// The response holds the final message and parts; the stream adds the earlier
+	// messages of multi-step runs
+	stats.observeMessage(response.Info)
+	for _, part := range response.Parts {
+		stats.observePart(part)
+	}
+
+	result := &AgentResult{
 		Output:    output,
 		SessionID: sessionID,
-	}, nil
+		Elapsed:   Duration(elapsed.Round(time.Millisecond)),
+	}
+	if params.Agent.Present {
+		result.Agent = params.Agent.Value
+	}
+	stats.apply(result)
+	c.log("Usage: model=%s, tokens=%+v, cost=$%.4f, tool calls=%d", result.Model, result.Usage, result.Cost, len(result.ToolCalls))
+
+	return result, nil
 }
 
 // PrintSessionFollowUp prints session follow-up instructions
@@ -649,9 +891,14 @@ type Logger struct {
 	sessionID string
 }
 
+// LogDir returns where a tool's logs live: ~/.cache/scripts/<tool>
+func LogDir(toolName string) string {
+	return filepath.Join(os.Getenv("HOME"), ".cache", "scripts", toolName)
+}
+
 // NewLogger creates a new streaming logger for the given tool (new session)
 func NewLogger(toolName string) (*Logger, error) {
-	logDir := filepath.Join(os.Getenv("HOME"), ".cache", "scripts", toolName)
+	logDir := LogDir(toolName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log dir %s: %w", logDir, err)
 	}
@@ -681,7 +928,7 @@ func NewLogger(toolName string) (*Logger, error) {
 // NewLoggerForSession creates/appends to a session-specific log file
 // Use this when continuing a session with -s SESSION_ID so all logs for that session are in one file
 func NewLoggerForSession(toolName, sessionID string) (*Logger, error) {
-	logDir := filepath.Join(os.Getenv("HOME"), ".cache", "scripts", toolName)
+	logDir := LogDir(toolName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log dir %s: %w", logDir, err)
 	}
@@ -731,6 +978,38 @@ func (l *Logger) LinkSession(sessionID string) {
 	}
 }
 
+// SessionLog is a session-<id>.log entry in a tool's log dir
+type SessionLog struct {
+	ID      string
+	Path    string
+	ModTime time.Time // Last write to the log, i.e. the session's last activity
+}
+
+// RecentSessions lists the tool's sessions found in its log dir, most recently active first
+// Returns at most limit entries (0 = all)
+func RecentSessions(toolName string, limit int) []SessionLog {
+	paths, _ := filepath.Glob(filepath.Join(LogDir(toolName), "session-*.log"))
+
+	var sessions []SessionLog
+	for _, path := range paths {
+		// Stat follows the symlink, so this is the underlying log's last write
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "session-"), ".log")
+		sessions = append(sessions, SessionLog{ID: id, Path: path, ModTime: info.ModTime()})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ModTime.After(sessions[j].ModTime)
+	})
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions
+}
+
 // Log writes a timestamped message to the log file (realtime flush)
 func (l *Logger) Log(format string, args ...interface{}) {
 	l.mu.Lock()
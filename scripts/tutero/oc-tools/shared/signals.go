@@ -0,0 +1,160 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// InterruptedError is returned when SIGINT or SIGTERM stopped a run
+// The session (if one was created) was aborted on the server and can be continued with -s
+type InterruptedError struct {
+	SessionID string
+	Signal    os.Signal
+}
+
+func (e *InterruptedError) Error() string {
+	if e.SessionID == "" {
+		return fmt.Sprintf("interrupted by %v before a session was created", e.Signal)
+	}
+	return fmt.Sprintf("interrupted by %v, session %s aborted", e.Signal, e.SessionID)
+}
+
+// AbortSession stops whatever the session is generating on the server
+// Uses its own short deadline so it works after the run's context was cancelled
+func (c *Client) AbortSession(sessionID, workDir string) error {
+	c.log("Aborting session: %s", sessionID)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.Session.Abort(ctx, sessionID, opencode.SessionAbortParams{
+		Directory: opencode.F(workDir),
+	})
+	if err != nil {
+		c.log("Session abort error: %v", err)
+		return fmt.Errorf("failed to abort session: %w", err)
+	}
+	c.log("Session aborted")
+	return nil
+}
+
+// runSignals is the SIGINT/SIGTERM handling of one run, from session creation through
+// cleanup (see watchSignals)
+type runSignals struct {
+	c       *Client
+	signals chan os.Signal
+	done    chan struct{}
+	parent  context.Context // c.ctx before the run
+	cancel  context.CancelFunc
+
+	mu        sync.Mutex
+	sessionID string // Session the first signal aborts (empty until the run has one)
+	workDir   string
+	received  os.Signal
+}
+
+// watchSignals handles SIGINT and SIGTERM until stop is called. For the duration, c.ctx is
+// a context the first signal cancels, so whatever the run is waiting on returns: session
+// creation, a retry backoff or the prompt. The run's session, once it has one, is aborted
+// on the server first so it stops burning tokens. A second signal exits at once, after
+// deleting throwaway sessions.
+func (c *Client) watchSignals() *runSignals {
+	ctx, cancel := context.WithCancel(c.ctx)
+	s := &runSignals{
+		c:       c,
+		signals: make(chan os.Signal, 2),
+		done:    make(chan struct{}),
+		parent:  c.ctx,
+		cancel:  cancel,
+	}
+	c.ctx = ctx
+	c.signals = s
+	signal.Notify(s.signals, syscall.SIGINT, syscall.SIGTERM)
+	go s.watch()
+	return s
+}
+
+func (s *runSignals) watch() {
+	c := s.c
+	select {
+	case sig := <-s.signals:
+		s.mu.Lock()
+		s.received = sig
+		sessionID, workDir := s.sessionID, s.workDir
+		s.mu.Unlock()
+		if sessionID != "" {
+			c.log("Received %v, aborting session %s", sig, sessionID)
+			fmt.Fprintf(os.Stderr, "\n[%v] Aborting session %s (again to quit now)...\n", sig, sessionID)
+			c.AbortSession(sessionID, workDir)
+		} else {
+			c.log("Received %v before a session was created, stopping", sig)
+			fmt.Fprintf(os.Stderr, "\n[%v] Stopping (again to quit now)...\n", sig)
+		}
+		s.cancel()
+	case <-s.done:
+		return
+	}
+
+	select {
+	case sig := <-s.signals:
+		c.log("Received %v again, exiting without waiting", sig)
+		// Already aborted; a throwaway session must still go
+		c.cleanupSessions(false)
+		if c.logger != nil {
+			c.logger.Close()
+		}
+		os.Exit(130)
+	case <-s.done:
+	}
+}
+
+// session sets the session a signal aborts
+func (s *runSignals) session(sessionID, workDir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessionID, s.workDir = sessionID, workDir
+}
+
+// signal returns the signal received so far, if any
+func (s *runSignals) signal() os.Signal {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received
+}
+
+// stop restores c.ctx and default signal handling and returns the signal received, if any
+func (s *runSignals) stop() os.Signal {
+	signal.Stop(s.signals)
+	close(s.done)
+	s.c.ctx = s.parent
+	s.c.signals = nil
+	s.cancel()
+	return s.signal()
+}
+
+// interrupted turns the error of a run a signal stopped into an *InterruptedError
+// (err may be anything the interrupted step failed with, e.g. a cancelled context),
+// keeping the usage a *UsageError carries
+func interrupted(err error, sig os.Signal, sessionID string) error {
+	var already *InterruptedError
+	if sig == nil || err == nil || errors.As(err, &already) {
+		return err
+	}
+	stopped := &InterruptedError{SessionID: sessionID, Signal: sig}
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		usage.Result.Error = stopped.Error()
+		return &UsageError{Result: usage.Result, Err: stopped}
+	}
+	return stopped
+}
@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestInterrupted(t *testing.T) {
+	backoff := fmt.Errorf("failed to create session: %w", context.Canceled)
+	already := &InterruptedError{SessionID: "ses_1", Signal: syscall.SIGINT}
+
+	tests := []struct {
+		name      string
+		err       error
+		signalled bool
+		sessionID string
+		want      string
+	}{
+		{"no signal", backoff, false, "", backoff.Error()},
+		{"signal after success", nil, true, "ses_1", ""},
+		{"during session creation", backoff, true, "", "interrupted by interrupt before a session was created"},
+		{"during a retry backoff", backoff, true, "ses_1", "interrupted by interrupt, session ses_1 aborted"},
+		{"during the prompt", already, true, "ses_1", already.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sig os.Signal
+			if tt.signalled {
+				sig = syscall.SIGINT
+			}
+			err := interrupted(tt.err, sig, tt.sessionID)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("interrupted() = %q, want %q", got, tt.want)
+			}
+			var ie *InterruptedError
+			if tt.signalled && tt.err != nil && !errors.As(err, &ie) {
+				t.Errorf("interrupted() = %T, want *InterruptedError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// textStream forwards the answer of a run to a writer while the prompt runs
+// Only parts of the run's own assistant messages are written (see runStats.answers);
+// the event stream also carries the user's prompt and any other session activity.
+// Parts that arrive before it is known whose message they belong to wait in pending.
+// It works from each part's accumulated text rather than the raw deltas, so a delta
+// missed before the event stream connected is caught up on the next update, and
+// finish writes whatever is still missing. Given the run's answer parts, finish leaves
+// exactly ExtractTextFromParts of them on out, followed by a newline (like fmt.Println),
+// unless a part was rewritten rather than appended to after it was written, or was
+// missed entirely while a later one streamed (finish can only append it).
+type textStream struct {
+	mu        sync.Mutex
+	out       io.Writer
+	reasoning io.Writer         // Receives reasoning parts (nil = not shown)
+	owned     map[string]bool   // Message ID -> whether it is one of the run's answers
+	pending   []opencode.Part   // Latest version of parts whose message isn't known yet
+	written   map[string]string // Text already written, per part ID
+	parts     int               // Text parts written so far
+	thinking  bool              // Last write went to reasoning
+}
+
+// newTextStream returns nil unless opts asks for streaming
+func newTextStream(opts *AgentOptions) *textStream {
+	if opts == nil || opts.Stream == nil {
+		return nil
+	}
+	return &textStream{
+		out:       opts.Stream,
+		reasoning: opts.StreamReasoning,
+		owned:     map[string]bool{},
+		written:   map[string]string{},
+	}
+}
+
+// message records whether a message is one of the run's answers, writing the parts
+// of it that were waiting
+func (s *textStream) message(id string, answer bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.owned[id] = answer
+	waiting := s.pending[:0]
+	for _, part := range s.pending {
+		if part.MessageID != id {
+			waiting = append(waiting, part)
+		} else if answer {
+			s.emit(part)
+		}
+	}
+	s.pending = waiting
+}
+
+// update writes the new text of a text or reasoning part of the run's answer
+func (s *textStream) update(part opencode.Part) {
+	if s == nil || part.Text == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	answer, known := s.owned[part.MessageID]
+	if known {
+		if answer {
+			s.emit(part)
+		}
+		return
+	}
+	for i := range s.pending {
+		if s.pending[i].ID == part.ID {
+			s.pending[i] = part
+			return
+		}
+	}
+	s.pending = append(s.pending, part)
+}
+
+// emit writes a text or reasoning part; must be called with mu held
+func (s *textStream) emit(part opencode.Part) {
+	switch part.Type {
+	case opencode.PartTypeText:
+		s.write(part.ID, part.Text)
+	case opencode.PartTypeReasoning:
+		if s.reasoning == nil {
+			return
+		}
+		done, seen := s.written[part.ID]
+		if !strings.HasPrefix(part.Text, done) {
+			return
+		}
+		if !seen {
+			fmt.Fprint(s.reasoning, "\n[thinking] ")
+		}
+		fmt.Fprint(s.reasoning, part.Text[len(done):])
+		s.written[part.ID] = part.Text
+		s.thinking = true
+	}
+}
+
+// write appends the unwritten tail of a text part, separating parts with a newline
+// like ExtractTextFromParts does; must be called with mu held
+func (s *textStream) write(id, text string) {
+	done, seen := s.written[id]
+	if !strings.HasPrefix(text, done) {
+		// The part was rewritten rather than appended to; what's out is out
+		return
+	}
+	if s.thinking {
+		fmt.Fprintln(s.reasoning)
+		s.thinking = false
+	}
+	if !seen && s.parts > 0 {
+		fmt.Fprint(s.out, "\n")
+	}
+	if !seen {
+		s.parts++
+	}
+	fmt.Fprint(s.out, text[len(done):])
+	s.written[id] = text
+}
+
+// finish writes any text the stream missed from the run's answer parts (all of its
+// assistant messages, in order) and ends the output with a newline
+func (s *textStream) finish(parts []opencode.Part) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, part := range parts {
+		if part.Type == opencode.PartTypeText && part.Text != "" {
+			s.write(part.ID, part.Text)
+		}
+	}
+	if s.thinking {
+		fmt.Fprintln(s.reasoning)
+		s.thinking = false
+	}
+	fmt.Fprintln(s.out)
+}
@@ -0,0 +1,137 @@
+package shared
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func textPart(id, messageID, text string) opencode.Part {
+	return opencode.Part{ID: id, MessageID: messageID, Type: opencode.PartTypeText, Text: text}
+}
+
+// streamEvent is one thing the event stream tells the textStream
+type streamEvent struct {
+	message string // Message ID to mark, if set
+	answer  bool   // Whether that message is one of the run's answers
+	part    opencode.Part
+}
+
+func TestTextStreamMatchesOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []streamEvent
+		final     []opencode.Part // The run's answer parts, as answerParts returns them
+		reasoning string
+	}{
+		{
+			name: "prompt is not echoed",
+			events: []streamEvent{
+				{message: "msg_user", answer: false},
+				{part: textPart("prt_prompt", "msg_user", "What is 2+2?")},
+				{part: textPart("prt_file", "msg_user", "Called the Read tool with note.txt")},
+				{message: "msg_a1", answer: true},
+				{part: textPart("prt_1", "msg_a1", "It")},
+				{part: textPart("prt_1", "msg_a1", "It is 4")},
+			},
+			final: []opencode.Part{textPart("prt_1", "msg_a1", "It is 4")},
+		},
+		{
+			name: "parts before their message is known",
+			events: []streamEvent{
+				{part: textPart("prt_prompt", "msg_user", "prompt")},
+				{part: textPart("prt_1", "msg_a1", "Hel")},
+				{part: textPart("prt_1", "msg_a1", "Hello")},
+				{message: "msg_user", answer: false},
+				{message: "msg_a1", answer: true},
+				{part: textPart("prt_1", "msg_a1", "Hello there")},
+			},
+			final: []opencode.Part{textPart("prt_1", "msg_a1", "Hello there")},
+		},
+		{
+			name: "multi-step answer",
+			events: []streamEvent{
+				{message: "msg_a1", answer: true},
+				{part: textPart("prt_1", "msg_a1", "Let me look.")},
+				{part: opencode.Part{ID: "prt_tool", MessageID: "msg_a1", Type: opencode.PartTypeTool, Tool: "read"}},
+				{message: "msg_a2", answer: true},
+				{part: textPart("prt_2", "msg_a2", "Found it")},
+			},
+			final: []opencode.Part{
+				textPart("prt_1", "msg_a1", "Let me look."),
+				{ID: "prt_tool", MessageID: "msg_a1", Type: opencode.PartTypeTool, Tool: "read"},
+				textPart("prt_2", "msg_a2", "Found it: line 3"),
+			},
+		},
+		{
+			name: "text missed before the stream connected",
+			events: []streamEvent{
+				{message: "msg_a1", answer: true},
+			},
+			final: []opencode.Part{textPart("prt_1", "msg_a1", "All of it")},
+		},
+		{
+			name: "other messages of the session are left out",
+			events: []streamEvent{
+				{message: "msg_old", answer: false},
+				{part: textPart("prt_old", "msg_old", "an earlier answer")},
+				{message: "msg_a1", answer: true},
+				{part: opencode.Part{ID: "prt_r", MessageID: "msg_a1", Type: opencode.PartTypeReasoning, Text: "thinking"}},
+				{part: opencode.Part{ID: "prt_r2", MessageID: "msg_old", Type: opencode.PartTypeReasoning, Text: "old thoughts"}},
+				{part: textPart("prt_1", "msg_a1", "New answer")},
+			},
+			final:     []opencode.Part{textPart("prt_1", "msg_a1", "New answer")},
+			reasoning: "\n[thinking] thinking\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, reasoning bytes.Buffer
+			stream := newTextStream(&AgentOptions{Stream: &out, StreamReasoning: &reasoning})
+			for _, ev := range tt.events {
+				if ev.message != "" {
+					stream.message(ev.message, ev.answer)
+				} else {
+					stream.update(ev.part)
+				}
+			}
+			stream.finish(tt.final)
+
+			if want := ExtractTextFromParts(tt.final) + "\n"; out.String() != want {
+				t.Errorf("streamed %q, want the output %q", out.String(), want)
+			}
+			if reasoning.String() != tt.reasoning {
+				t.Errorf("reasoning %q, want %q", reasoning.String(), tt.reasoning)
+			}
+		})
+	}
+}
+
+func TestRunStatsAnswers(t *testing.T) {
+	stats := newRunStats()
+	stats.addPrompt("msg_1")
+	stats.addPrompt("msg_2") // A retry or fallback sends another prompt
+
+	for parent, want := range map[string]bool{"msg_1": true, "msg_2": true, "msg_0": false, "": false} {
+		if got := stats.answers(opencode.AssistantMessage{ID: "msg_a", ParentID: parent}); got != want {
+			t.Errorf("answers(parent %q) = %v, want %v", parent, got, want)
+		}
+	}
+}
+
+func TestNewMessageID(t *testing.T) {
+	prev := ""
+	for i := 0; i < 1000; i++ {
+		id := newMessageID()
+		if len(id) != len("msg_")+26 || !strings.HasPrefix(id, "msg_") {
+			t.Fatalf("newMessageID() = %q, want msg_ and 26 characters", id)
+		}
+		// The time part orders IDs, as the server orders messages by ID
+		if prev != "" && id[:16] <= prev[:16] {
+			t.Fatalf("%q does not sort after %q", id, prev)
+		}
+		prev = id
+	}
+}
@@ -0,0 +1,700 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Tool declares an agent-backed command; Run turns it into a complete CLI with the
+// flags, logging, session handling and output every tool shares:
+//
+//	func main() {
+//		shared.Run(&shared.Tool{
+//			Name:     "big-brain",
+//			Usage:    `Usage: big-brain [-s SESSION_ID] "prompt"`,
+//			Timeout:  10 * time.Minute,
+//			Isolated: true,
+//		})
+//	}
+type Tool struct {
+	Name    string   // Command name; also the log dir and the [tools.<name>] config section
+	Aliases []string // Other names the tool answers to (e.g. `oc run` for oc-run)
+	Agent   string   // opencode agent to run (defaults to Name)
+
+	// Help text; the common options are added by the runner
+	Summary     string // One line for command lists (oc help)
+	Usage       string // Synopsis lines ("Usage: ..." / "   or: ...")
+	Description string // Paragraph shown below the synopsis
+	Flags       string // Help lines for tool-specific flags, listed before the common ones
+	ModelHelp   string // Help for --model (default "Override the agent's model")
+	Notes       string
+	Examples    string
+
+	WorkDir       string        // Default agent directory, ~ expanded ("" = where the tool was invoked)
+	PromptPrefix  string        // Prepended to every prompt
+	Timeout       time.Duration // Built-in default timeout (config, env and --timeout override it)
+	Model         string        // Built-in default model as provider/model ("" = the agent's own)
+	FallbackModel string        // Built-in default for fallback_model
+	ModelPolicy   *ModelPolicy  // Picks the model from what the server offers unless one is configured
+	Budget        *Budget       // Built-in default budget per run (nil = unlimited)
+	Isolated      bool          // Keep sessions out of the main opencode history (IsolateDataDir)
+	RequireStdin  bool          // Prompt must be piped in; positional args are not a prompt
+	Quiet         bool          // Print only the agent output (no session banners or follow-up hint)
+	AutoCleanup   bool          // Delete the session once it has answered
+	ArgFlags      []string      // Flags consumed by ParseArgs, for completion (e.g. --dirs)
+
+	// Optional hooks, called in this order
+	ParseArgs func(args []string) []string                 // Consumes arguments the flag package can't parse, returns the rest
+	SetFlags  func(fs *flag.FlagSet)                       // Registers tool-specific flags
+	Prepare   func(inv *Invocation) error                  // Validates flags and rewrites inv.Prompt before connecting
+	Options   func(inv *Invocation) (*AgentOptions, error) // Builds agent options once inv.Client is connected
+}
+
+// Environment shared with external tools (see cmd/oc/plugins.go)
+const (
+	EnvServerURL     = "OC_TOOLS_SERVER_URL"     // opencode server the tools are using (also exported as OPENCODE_URL)
+	EnvParentSession = "OC_TOOLS_PARENT_SESSION" // Session new sessions are created under
+	EnvLogDir        = "OC_TOOLS_LOG_DIR"        // Log directory reserved for the tool
+)
+
+// Invocation is the state of one tool run, handed to the Tool hooks
+type Invocation struct {
+	Tool        *Tool
+	Settings    ToolConfig // Effective config (defaults < config file < env < flags)
+	Args        []string   // Positional arguments left after flag parsing
+	SessionID   string     // Session being continued (empty for a new one)
+	Fork        *ForkPoint // --fork: the new session starts from a copy of this history
+	Verbose     bool
+	JSON        bool           // Print the result as one JSON object instead of text
+	Stream      bool           // Print the answer as it is generated
+	Reasoning   bool           // Also stream reasoning to stderr (implies Stream)
+	Files       []string       // --file paths as given, then the prompt's @path references
+	Ephemeral   bool           // Delete the session when the run ends (like Tool.AutoCleanup)
+	Agent       string         // Agent to run (Tool.Agent unless a hook changes it)
+	Prompt      string         // Prompt as it will be sent (hooks may rewrite it)
+	Model       *ModelConfig   // --model / config override (nil = agent default)
+	Fallbacks   []*ModelConfig // Rest of the configured model chain, tried when Model fails
+	Attachments []*Attachment  // Files, checked and resolved against InvokeDir
+	InvokeDir   string         // Directory the tool was invoked from
+	WorkDir     string         // Directory the agent runs in
+	Logger      *Logger        // May be nil if the log file could not be created
+	Client      *Client        // Set before Options is called
+}
+
+// Log writes to the invocation's log file, if there is one
+func (inv *Invocation) Log(format string, args ...interface{}) {
+	if inv.Logger != nil {
+		inv.Logger.Log(format, args...)
+	}
+}
+
+// Run executes the tool with os.Args and exits with its status
+func Run(t *Tool) {
+	os.Exit(t.Execute(os.Args[1:]))
+}
+
+// Execute runs the tool with args (without the program name) and returns the exit status:
+// 0 on success, 1 on failure, 2 on invalid flags, 3 when the agent failed or timed out
+// but partial output was printed, 130 when interrupted (Ctrl-C)
+func (t *Tool) Execute(args []string) int {
+	// The environment as the user gave it, before isolation rewrites it (for auto GC)
+	env := os.Environ()
+
+	if t.Isolated {
+		// Isolate sessions from main opencode CLI
+		IsolateDataDir()
+	}
+
+	// `<tool> --daemon` keeps a warm server for later invocations
+	HandleDaemonFlag(args)
+
+	// Defaults from ~/.config/oc-tools/config.toml and OC_TOOLS_* env, flags override below
+	inv := &Invocation{
+		Tool:     t,
+		Settings: t.Settings(),
+	}
+
+	var showHelp bool
+	fs := t.flagSet(inv, &showHelp)
+
+	if t.ParseArgs != nil {
+		args = t.ParseArgs(args)
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	inv.Args = fs.Args()
+
+	if showHelp {
+		fmt.Fprint(os.Stderr, t.Help())
+		return 0
+	}
+
+	// Housekeeping of old logs and sessions (config: auto_gc); the --daemon child
+	// never gets here
+	MaybeAutoGC(env)
+
+	// Setup logging FIRST (before any other operations)
+	// Use session-specific log file if continuing, otherwise create new timestamped log
+	var logErr error
+	if inv.SessionID != "" {
+		inv.Logger, logErr = NewLoggerForSession(t.Name, inv.SessionID)
+	} else {
+		inv.Logger, logErr = NewLogger(t.Name)
+	}
+	if logErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not setup logging: %v\n", logErr)
+	}
+	if inv.Logger != nil {
+		defer inv.Logger.Close()
+	}
+
+	inv.Log("Arguments: session=%s, fork=%v, verbose=%v, args=%v", inv.SessionID, inv.Fork, inv.Verbose, inv.Args)
+	inv.Log("Settings: timeout=%v, model=%q, models=%q, fallback=%q, workdir=%q, attempts=%d, backoff=%v, budget=%v, config=%q",
+		inv.Settings.Timeout, inv.Settings.Model, inv.Settings.Models, inv.Settings.FallbackModel, inv.Settings.WorkDir,
+		inv.Settings.MaxAttempts, inv.Settings.RetryBackoff, inv.Settings.Budget, LoadConfig().Path())
+
+	fail := func(err error) int {
+		inv.Log("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		inv.printJSONError(err)
+		return 1
+	}
+
+	if inv.Ephemeral && inv.SessionID != "" {
+		fmt.Fprintln(os.Stderr, "Error: --ephemeral starts a throwaway session; it can't be combined with -s")
+		return 2
+	}
+	if inv.Fork != nil && inv.SessionID != "" {
+		fmt.Fprintln(os.Stderr, "Error: --fork starts a new session; it can't be combined with -s")
+		return 2
+	}
+
+	// Catch a malformed model early; the chain itself is resolved once connected
+	_, err := inv.Settings.ModelChain()
+	if err != nil {
+		return fail(err)
+	}
+
+	// Read prompt from args first, fall back to stdin (stdin only for RequireStdin tools)
+	promptArgs := inv.Args
+	if t.RequireStdin {
+		promptArgs = nil
+		if stat, _ := os.Stdin.Stat(); stat.Mode()&os.ModeCharDevice != 0 {
+			status := fail(errors.New("prompt must be provided via stdin (pipe or redirect)"))
+			fmt.Fprint(os.Stderr, t.Help())
+			return status
+		}
+	}
+	if inv.Prompt, err = ReadStdinOrArgs(promptArgs); err != nil {
+		status := fail(err)
+		fmt.Fprint(os.Stderr, t.Help())
+		return status
+	}
+
+	rawPrompt := inv.Prompt // Before hooks and PromptPrefix rewrite it
+	inv.Agent = t.agent()
+	inv.InvokeDir = GetWorkDir()
+	inv.WorkDir = inv.Settings.ResolveWorkDir(t.defaultWorkDir())
+
+	if inv.Logger != nil {
+		inv.Logger.LogSeparator("INPUT")
+		inv.Logger.Log("Invoke directory: %s", inv.InvokeDir)
+		inv.Logger.Log("Raw prompt:\n%s", inv.Prompt)
+	}
+
+	// @path in the prompt attaches the file like --file does
+	var refs []string
+	inv.Prompt, refs = ExpandAttachments(inv.Prompt, inv.InvokeDir)
+	inv.Files = append(inv.Files, refs...)
+	for _, path := range inv.Files {
+		attachment, err := LoadAttachment(path, inv.InvokeDir)
+		if err != nil {
+			return fail(err)
+		}
+		inv.Log("Attachment: %s", attachment)
+		inv.Attachments = append(inv.Attachments, attachment)
+	}
+
+	if t.Prepare != nil {
+		if err := t.Prepare(inv); err != nil {
+			return fail(err)
+		}
+	}
+	if t.PromptPrefix != "" {
+		inv.Prompt = t.PromptPrefix + inv.Prompt
+	}
+	inv.Log("Final prompt length: %d chars", len(inv.Prompt))
+
+	if inv.Logger != nil {
+		inv.Logger.LogSeparator("SDK SETUP")
+		inv.Logger.Log("Work directory: %s", inv.WorkDir)
+	}
+
+	ctx, cancel := runContext(inv.Settings.Timeout)
+	defer cancel()
+
+	inv.Client = NewClientWithLogger(ctx, inv.Logger)
+	defer inv.Client.Close()
+
+	chain, source, err := t.ModelChain(inv.Settings, inv.Client)
+	if err != nil {
+		return fail(err)
+	}
+	inv.Log("Model chain (%s): %v", source, chain)
+	if len(chain) > 0 {
+		inv.Model, inv.Fallbacks = chain[0], chain[1:]
+	}
+
+	opts := &AgentOptions{Model: inv.Model, Fallbacks: inv.Fallbacks}
+	if t.Options != nil {
+		if opts, err = t.Options(inv); err != nil {
+			return fail(err)
+		}
+	}
+	opts.Quiet = opts.Quiet || t.Quiet || inv.JSON
+	opts.AutoCleanup = opts.AutoCleanup || t.AutoCleanup || inv.Ephemeral
+	if inv.Stream || inv.Reasoning {
+		// Keep stdout for the JSON object when both are asked for
+		opts.Stream = os.Stdout
+		if inv.JSON {
+			opts.Stream = os.Stderr
+		}
+		if inv.Reasoning {
+			opts.StreamReasoning = os.Stderr
+		}
+	}
+	if opts.Fallbacks == nil {
+		opts.Fallbacks = inv.Fallbacks
+	}
+	if opts.Retry == nil {
+		opts.Retry = inv.Settings.RetryPolicy()
+	}
+	if opts.Budget == nil {
+		opts.Budget = inv.Settings.Budget
+	}
+	if opts.Attachments == nil {
+		opts.Attachments = inv.Attachments
+	}
+	if opts.Tool == "" {
+		opts.Tool = t.Name
+	}
+	if opts.Summary == "" {
+		opts.Summary = rawPrompt
+	}
+	if opts.Fork == nil {
+		opts.Fork = inv.Fork
+	}
+	if opts.ParentID == "" {
+		// Set when an external tool (oc-tool-*) runs us on behalf of its own session
+		opts.ParentID = os.Getenv(EnvParentSession)
+	}
+
+	if inv.Logger != nil {
+		inv.Logger.LogSeparator("AGENT CALL")
+	}
+
+	result, err := t.callAgent(inv, opts)
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		inv.recordUsage(usage.Result)
+	}
+	var interrupted *InterruptedError
+	if errors.As(err, &interrupted) {
+		inv.Log("INTERRUPTED: %v", err)
+		inv.printJSONError(err)
+		if inv.Verbose && inv.Logger != nil {
+			fmt.Fprintf(os.Stderr, "[debug] Logs saved to: %s\n", inv.Logger.Path())
+		}
+		if interrupted.SessionID == "" {
+			fmt.Fprintf(os.Stderr, "Interrupted before a session was created\n")
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
+		if opts.AutoCleanup {
+			fmt.Fprintf(os.Stderr, "Session aborted and deleted: %s\n", interrupted.SessionID)
+		} else {
+			fmt.Fprintf(os.Stderr, "Session aborted: %s\n", interrupted.SessionID)
+			fmt.Fprintf(os.Stderr, "To resume: %s -s %s\n", t.Name, interrupted.SessionID)
+		}
+		fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n")
+		return 130
+	}
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		return t.printPartial(inv, opts, partial)
+	}
+	if err != nil {
+		inv.Log("ERROR: agent call failed: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		inv.printJSONError(err)
+		return 1
+	}
+
+	inv.recordUsage(result)
+
+	// Link session log for new sessions so future continuations find it
+	if inv.Logger != nil && inv.SessionID == "" && !opts.AutoCleanup {
+		inv.Logger.LinkSession(result.SessionID)
+	}
+
+	if inv.Logger != nil {
+		inv.Logger.LogSeparator("RESULT")
+		inv.Logger.Log("Session ID: %s", result.SessionID)
+		inv.Logger.Log("Model: %s (failed first: %v)", result.Model, result.ModelsTried)
+		inv.Logger.Log("Output length: %d chars", len(result.Output))
+	}
+
+	if len(result.ModelsTried) > 0 && !inv.JSON {
+		fmt.Fprintf(os.Stderr, "[answered by fallback model %s]\n", result.Model)
+	}
+
+	if inv.Verbose && inv.Logger != nil {
+		fmt.Fprintf(os.Stderr, "[debug] Logs saved to: %s\n", inv.Logger.Path())
+	}
+
+	if inv.JSON {
+		if inv.Logger != nil {
+			result.LogPath = inv.Logger.Path()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Print output (already on stdout if streamed)
+	if opts.Stream == nil {
+		fmt.Println(result.Output)
+	}
+
+	// Print session follow-up instructions (a cleaned-up session can't be followed up)
+	if !opts.Quiet && !opts.AutoCleanup {
+		PrintSessionFollowUp(t.Name, result.SessionID)
+	}
+	return 0
+}
+
+// printPartial prints what the agent produced before it failed or timed out, marked as
+// partial on stdout so whoever reads the output knows it is incomplete, and returns 3
+func (t *Tool) printPartial(inv *Invocation, opts *AgentOptions, partial *PartialError) int {
+	result := partial.Result
+	inv.Log("PARTIAL: %v (%d chars recovered)", partial.Err, len(result.Output))
+	fmt.Fprintf(os.Stderr, "Error: %v\n", partial.Err)
+	inv.recordUsage(result)
+
+	if inv.Logger != nil {
+		inv.Logger.LogSeparator("PARTIAL RESULT")
+		inv.Logger.Log("Session ID: %s", result.SessionID)
+		inv.Logger.Log("Output length: %d chars", len(result.Output))
+		if inv.SessionID == "" && !opts.AutoCleanup {
+			inv.Logger.LinkSession(result.SessionID)
+		}
+	}
+
+	if inv.JSON {
+		if inv.Logger != nil {
+			result.LogPath = inv.Logger.Path()
+		}
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+		return 3
+	}
+
+	// A streamed answer is already on stdout, so the marker just shows where it stopped
+	fmt.Printf("[PARTIAL OUTPUT: %v]\n", partial.Err)
+	if opts.Stream == nil {
+		fmt.Println(result.Output)
+		fmt.Println("[END OF PARTIAL OUTPUT]")
+	}
+
+	if !opts.AutoCleanup {
+		fmt.Fprintf(os.Stderr, "\n────────────────────────────────────────────────────────────────\n")
+		fmt.Fprintf(os.Stderr, "Session incomplete: %s\n", result.SessionID)
+		fmt.Fprintf(os.Stderr, "To pick up where it stopped: %s -s %s \"continue\"\n", t.Name, result.SessionID)
+		fmt.Fprintf(os.Stderr, "────────────────────────────────────────────────────────────────\n")
+	}
+	return 3
+}
+
+// recordUsage appends the run to the usage ledger (see `oc usage`)
+// A ledger that can't be written only costs the record, never the run's output
+func (inv *Invocation) recordUsage(result *AgentResult) {
+	err := RecordUsage(LedgerEntry{
+		Time:      time.Now(),
+		Tool:      inv.Tool.Name,
+		SessionID: result.SessionID,
+		Model:     result.Model,
+		WorkDir:   inv.InvokeDir,
+		Usage:     result.Usage,
+		Cost:      result.Cost,
+		Elapsed:   result.Elapsed,
+		Partial:   result.Partial,
+		Error:     result.Error,
+	})
+	if err != nil {
+		inv.Log("Warning: could not record usage: %v", err)
+	}
+}
+
+// printJSONError prints a failed run as {"error": ...} on stdout in --json mode, so
+// scripts reading stdout always get an object
+func (inv *Invocation) printJSONError(err error) {
+	if !inv.JSON {
+		return
+	}
+	out := map[string]string{"error": err.Error()}
+	var interrupted *InterruptedError
+	if errors.As(err, &interrupted) && interrupted.SessionID != "" {
+		out["session_id"] = interrupted.SessionID
+	} else if inv.SessionID != "" {
+		out["session_id"] = inv.SessionID
+	}
+	if inv.Logger != nil {
+		out["log_path"] = inv.Logger.Path()
+	}
+	data, _ := json.Marshal(out)
+	fmt.Println(string(data))
+}
+
+// flagSet registers the common flags, the config overrides and the tool's own flags
+func (t *Tool) flagSet(inv *Invocation, showHelp *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(t.Name, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, t.Help()) }
+	fs.StringVar(&inv.SessionID, "s", "", "Continue an existing session")
+	fs.StringVar(&inv.SessionID, "session", "", "Continue an existing session")
+	fs.BoolVar(&inv.Verbose, "v", false, "Verbose mode")
+	fs.BoolVar(&inv.JSON, "json", false, "Print the result as JSON")
+	fs.BoolVar(&inv.Stream, "stream", false, "Print the answer as it is generated")
+	fs.BoolVar(&inv.Reasoning, "reasoning", false, "Stream reasoning to stderr")
+	fs.BoolVar(&inv.Ephemeral, "ephemeral", false, "Delete the session when the run ends")
+	fs.Func("fork", "Start from a copy of SESSION[:MESSAGE]", func(s string) error {
+		fork, err := ParseForkPoint(s)
+		inv.Fork = fork
+		return err
+	})
+	fs.Func("file", "Attach a file (repeatable)", func(path string) error {
+		inv.Files = append(inv.Files, path)
+		return nil
+	})
+	fs.BoolVar(showHelp, "help", false, "Show help")
+	fs.BoolVar(showHelp, "h", false, "Show help")
+	inv.Settings.RegisterFlags(fs)
+	if t.SetFlags != nil {
+		t.SetFlags(fs)
+	}
+	return fs
+}
+
+// FlagNames lists every flag the tool accepts as typed on the command line (-s, --session, ...)
+// Used for shell completion
+func (t *Tool) FlagNames() []string {
+	var showHelp bool
+	var names []string
+	t.flagSet(&Invocation{Tool: t}, &showHelp).VisitAll(func(f *flag.Flag) {
+		if len(f.Name) <= 2 {
+			names = append(names, "-"+f.Name)
+		} else {
+			names = append(names, "--"+f.Name)
+		}
+	})
+	return append(names, t.ArgFlags...)
+}
+
+// Models a policy contributes to the model chain (the best ones it ranks)
+const policyPicks = 2
+
+// Settings returns the tool's effective settings before command-line flags:
+// built-in defaults < config file < OC_TOOLS_* env
+func (t *Tool) Settings() ToolConfig {
+	return LoadConfig().Tool(t.Name, ToolConfig{
+		Timeout:       Duration(t.Timeout),
+		Model:         t.Model,
+		FallbackModel: t.FallbackModel,
+		Budget:        t.Budget,
+	})
+}
+
+// ModelChain resolves the models a run tries in order, and where the first one comes
+// from: "config" (model/models), "policy", "fallback_model" or "agent default" (empty chain)
+// A configured model wins over the policy; the policy's best picks come before fallback_model.
+// If the server can't be asked, the policy is skipped with a warning.
+func (t *Tool) ModelChain(settings ToolConfig, c *Client) ([]*ModelConfig, string, error) {
+	chain, err := settings.ModelChain()
+	if err != nil {
+		return nil, "", err
+	}
+	if settings.Explicit() {
+		return chain, "config", nil
+	}
+
+	var picked []*ModelConfig
+	if policy := t.Policy(settings); policy != nil {
+		picks, err := c.SelectModels(policy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not query models: %v\n", err)
+		}
+		for i := 0; i < len(picks) && i < policyPicks; i++ {
+			picked = append(picked, picks[i].Model())
+		}
+	}
+	merged, source := mergeChain(picked, chain)
+	return merged, source, nil
+}
+
+// mergeChain puts the policy's picks before the configured chain (fallback_model),
+// dropping models already in it, and says where the first model comes from. The policy
+// gets the credit whenever it picked anything, even the fallback model itself.
+func mergeChain(picked, chain []*ModelConfig) ([]*ModelConfig, string) {
+	merged := append([]*ModelConfig{}, picked...)
+	for _, model := range chain {
+		if !containsModel(merged, model) {
+			merged = append(merged, model)
+		}
+	}
+
+	switch {
+	case len(merged) == 0:
+		return nil, "agent default"
+	case len(picked) > 0:
+		return merged, "policy"
+	}
+	return merged, "fallback_model"
+}
+
+// Policy returns the model policy in effect: [tools.<name>.policy] or the tool's own
+func (t *Tool) Policy(settings ToolConfig) *ModelPolicy {
+	if settings.Policy != nil {
+		return settings.Policy
+	}
+	return t.ModelPolicy
+}
+
+func containsModel(models []*ModelConfig, model *ModelConfig) bool {
+	for _, m := range models {
+		if *m == *model {
+			return true
+		}
+	}
+	return false
+}
+
+// callAgent starts or continues the session (the client retries transient failures)
+func (t *Tool) callAgent(inv *Invocation, opts *AgentOptions) (*AgentResult, error) {
+	if inv.SessionID != "" {
+		inv.Log("Continuing existing session: %s", inv.SessionID)
+		return inv.Client.ContinueSessionWithOptions(inv.SessionID, inv.Agent, inv.Prompt, inv.WorkDir, opts)
+	}
+	inv.Log("Starting new session")
+	return inv.Client.RunAgentWithOptions(inv.Agent, inv.Prompt, inv.WorkDir, opts)
+}
+
+// Is reports whether name is the tool's name or one of its aliases
+func (t *Tool) Is(name string) bool {
+	if t.Name == name {
+		return true
+	}
+	for _, alias := range t.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tool) agent() string {
+	if t.Agent != "" {
+		return t.Agent
+	}
+	return t.Name
+}
+
+func (t *Tool) defaultWorkDir() string {
+	if t.WorkDir == "" {
+		return GetWorkDir()
+	}
+	return ExpandHome(t.WorkDir)
+}
+
+// Help returns the tool's full help text: synopsis, tool flags, common flags, notes and examples
+func (t *Tool) Help() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(t.Usage, "\n") + "\n")
+	if t.Description != "" {
+		b.WriteString("\n" + strings.TrimRight(t.Description, "\n") + "\n")
+	}
+
+	workDir := t.WorkDir
+	if workDir == "" {
+		workDir = "current directory"
+	}
+	timeout := t.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	modelHelp := t.ModelHelp
+	if modelHelp == "" {
+		modelHelp = "Override the agent's model"
+	}
+
+	b.WriteString("\nOptions:\n")
+	b.WriteString(t.Flags)
+	option := func(name, help string) {
+		fmt.Fprintf(&b, "  %-28s%s\n", name, help)
+	}
+	option("-s, --session SESSION_ID", "Continue an existing session")
+	option("--fork SESSION[:MESSAGE]", "Continue in a new copy of a session, cut after MESSAGE")
+	option("-v", "Verbose mode (show logs location)")
+	option("--json", "Print one JSON object: output, session, model, usage, cost, tool calls")
+	option("--stream", "Print the answer as it is generated (to stderr with --json)")
+	option("--reasoning", "Stream the model's reasoning to stderr too (implies --stream)")
+	option("--ephemeral", "Delete the session when the run ends (nothing to continue)")
+	option("--file PATH", fmt.Sprintf("Attach a text file, image or PDF (repeatable, max %d MB)", MaxAttachmentSize>>20))
+	option("", "@path in the prompt attaches an existing file too")
+	option("--timeout DURATION", fmt.Sprintf("Overall timeout (default %s; 0 = none)", shortDuration(timeout)))
+	option("--model PROVIDER/MODEL", modelHelp)
+	option("--workdir DIR", fmt.Sprintf("Directory the agent runs in (default: %s)", workDir))
+	option("-h, --help", "Show this help message")
+	option("--daemon", "Run the keep-warm server daemon in the foreground")
+	option("", fmt.Sprintf("(OC_TOOLS_IDLE_TIMEOUT, default %s)", shortDuration(DefaultIdleTimeout)))
+
+	if t.Notes != "" {
+		b.WriteString("\n" + strings.TrimRight(t.Notes, "\n") + "\n")
+	}
+	if t.Examples != "" {
+		b.WriteString("\nExamples:\n" + strings.TrimRight(t.Examples, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// shortDuration formats 10m0s as 10m and 2h0m0s as 2h
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// runContext bounds a run by its overall timeout; --timeout 0 runs without one
+func runContext(timeout Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(timeout))
+}
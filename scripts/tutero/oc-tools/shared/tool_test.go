@@ -0,0 +1,157 @@
+package shared
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// models parses provider/model strings for tests
+func models(t *testing.T, names ...string) []*ModelConfig {
+	t.Helper()
+	var out []*ModelConfig
+	for _, name := range names {
+		model, err := ParseModel(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, model)
+	}
+	return out
+}
+
+func modelNames(chain []*ModelConfig) []string {
+	var names []string
+	for _, model := range chain {
+		names = append(names, model.String())
+	}
+	return names
+}
+
+func TestMergeChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		picked     []string
+		chain      []string
+		want       []string
+		wantSource string
+	}{
+		{"nothing", nil, nil, nil, "agent default"},
+		{"fallback only", nil, []string{"anthropic/claude-haiku-4-5"}, []string{"anthropic/claude-haiku-4-5"}, "fallback_model"},
+		{"policy before fallback", []string{"opencode/free-b", "opencode/free-a"}, []string{"anthropic/claude-haiku-4-5"},
+			[]string{"opencode/free-b", "opencode/free-a", "anthropic/claude-haiku-4-5"}, "policy"},
+		{"policy picks the fallback model", []string{"anthropic/claude-haiku-4-5"}, []string{"anthropic/claude-haiku-4-5"},
+			[]string{"anthropic/claude-haiku-4-5"}, "policy"},
+		{"policy picks a configured model", []string{"opencode/free-a"}, []string{"opencode/free-a", "anthropic/claude-haiku-4-5"},
+			[]string{"opencode/free-a", "anthropic/claude-haiku-4-5"}, "policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source := mergeChain(models(t, tt.picked...), models(t, tt.chain...))
+			if !reflect.DeepEqual(modelNames(got), tt.want) || source != tt.wantSource {
+				t.Errorf("mergeChain = %v (%s), want %v (%s)", modelNames(got), source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestToolModelChainWithoutPolicy(t *testing.T) {
+	tool := &Tool{Name: "test-tool", ModelPolicy: FreeModelPolicy()}
+
+	// A configured model wins without asking the server (no client needed)
+	settings := ToolConfig{Model: "anthropic/claude-opus-4-1", FallbackModel: "anthropic/claude-haiku-4-5"}
+	chain, source, err := tool.ModelChain(settings, nil)
+	if err != nil || source != "config" || !reflect.DeepEqual(modelNames(chain), []string{"anthropic/claude-opus-4-1", "anthropic/claude-haiku-4-5"}) {
+		t.Errorf("ModelChain = %v, %q, %v", modelNames(chain), source, err)
+	}
+
+	plain := &Tool{Name: "test-tool"}
+	chain, source, err = plain.ModelChain(ToolConfig{FallbackModel: "anthropic/claude-haiku-4-5"}, nil)
+	if err != nil || source != "fallback_model" || len(chain) != 1 {
+		t.Errorf("ModelChain = %v, %q, %v", modelNames(chain), source, err)
+	}
+}
+
+func TestToolConfigModelChain(t *testing.T) {
+	settings := ToolConfig{
+		Model:         "anthropic/claude-opus-4-1",
+		Models:        []string{" opencode/free-b", "anthropic/claude-opus-4-1"},
+		FallbackModel: "anthropic/claude-haiku-4-5",
+	}
+	chain, err := settings.ModelChain()
+	want := []string{"anthropic/claude-opus-4-1", "opencode/free-b", "anthropic/claude-haiku-4-5"}
+	if err != nil || !reflect.DeepEqual(modelNames(chain), want) {
+		t.Errorf("ModelChain = %v, %v; want %v", modelNames(chain), err, want)
+	}
+
+	if _, err := (ToolConfig{Model: "no-slash"}).ModelChain(); err == nil {
+		t.Error("ModelChain accepted a model without a provider")
+	}
+}
+
+func TestToolPolicy(t *testing.T) {
+	own := FreeModelPolicy()
+	configured := &ModelPolicy{Providers: []string{"anthropic"}}
+	tool := &Tool{Name: "test-tool", ModelPolicy: own}
+
+	if got := tool.Policy(ToolConfig{}); got != own {
+		t.Errorf("Policy() = %v, want the tool's own", got)
+	}
+	if got := tool.Policy(ToolConfig{Policy: configured}); got != configured {
+		t.Errorf("Policy() = %v, want the configured one", got)
+	}
+	if got := (&Tool{Name: "test-tool"}).Policy(ToolConfig{}); got != nil {
+		t.Errorf("Policy() = %v, want none", got)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	ctx, cancel := runContext(0)
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+		t.Errorf("runContext(0) has a deadline or is done (%v), want no timeout", ctx.Err())
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("runContext(0) not cancelled by its cancel func")
+	}
+
+	ctx, cancel = runContext(Duration(time.Hour))
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 59*time.Minute {
+		t.Errorf("runContext(1h) deadline = %v, want an hour from now", deadline)
+	}
+}
+
+func TestExecuteJSONErrorWithoutPrompt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	stderr, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin, oldStdout, oldStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = stdin, w, stderr
+	defer func() { os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr }()
+
+	status := (&Tool{Name: "test-tool", Agent: "build"}).Execute([]string{"--json"})
+	w.Close()
+	os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr
+	out, _ := io.ReadAll(r)
+
+	var result map[string]string
+	if err := json.Unmarshal(out, &result); err != nil || status != 1 || result["error"] == "" {
+		t.Errorf("Execute() = %d with stdout %q, want 1 and a JSON error", status, out)
+	}
+}
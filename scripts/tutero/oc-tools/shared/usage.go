@@ -0,0 +1,159 @@
+package shared
+
+import (
+	"sync"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Usage is the token usage of a run, summed over its assistant messages
+type Usage struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	Reasoning  float64 `json:"reasoning"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Total returns all tokens counted against the model
+func (u Usage) Total() float64 {
+	return u.Input + u.Output + u.Reasoning + u.CacheRead + u.CacheWrite
+}
+
+// ToolCall is one tool invocation made by the agent during a run
+type ToolCall struct {
+	Tool   string `json:"tool"`
+	Status string `json:"status"`          // pending, running, completed or error
+	Title  string `json:"title,omitempty"` // Short description set by the tool (e.g. the file read)
+	Error  string `json:"error,omitempty"`
+}
+
+// UsageError is a run that failed without output (see PartialError for one with), with
+// what it used before failing in Result: model, tokens, cost and elapsed time
+type UsageError struct {
+	Result *AgentResult
+	Err    error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// runStats accumulates usage and tool calls for one prompt from the event stream
+// Messages and parts are updated many times while streaming, so only the latest
+// version of each is kept (keyed by ID) and summed at the end.
+// It also knows the run's own user messages (one per attempt), which is how the
+// assistant messages answering them are told apart from the rest of the session.
+type runStats struct {
+	mu        sync.Mutex
+	prompts   map[string]bool // IDs of the user messages this run sent
+	messages  map[string]opencode.AssistantMessage
+	toolCalls map[string]ToolCall
+	toolOrder []string      // Part IDs in first-seen order
+	changed   chan struct{} // Signalled (without blocking) whenever usage or tool calls change
+}
+
+func newRunStats() *runStats {
+	return &runStats{
+		prompts:   map[string]bool{},
+		messages:  map[string]opencode.AssistantMessage{},
+		toolCalls: map[string]ToolCall{},
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+// addPrompt records the ID of a user message this run sends
+func (s *runStats) addPrompt(id string) {
+	s.mu.Lock()
+	s.prompts[id] = true
+	s.mu.Unlock()
+}
+
+// answers reports whether an assistant message replies to one of this run's prompts
+func (s *runStats) answers(msg opencode.AssistantMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prompts[msg.ParentID]
+}
+
+// observeMessage records the latest state of an assistant message
+func (s *runStats) observeMessage(msg opencode.AssistantMessage) {
+	if msg.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	s.messages[msg.ID] = msg
+	s.mu.Unlock()
+	s.notify()
+}
+
+// observePart records the latest state of a tool part (other parts are ignored)
+func (s *runStats) observePart(part opencode.Part) {
+	if part.Type != opencode.PartTypeTool {
+		return
+	}
+	call := ToolCall{Tool: part.Tool}
+	if state, ok := part.State.(opencode.ToolPartState); ok {
+		call.Status = string(state.Status)
+		call.Title = state.Title
+		call.Error = state.Error
+	}
+
+	s.mu.Lock()
+	if _, seen := s.toolCalls[part.ID]; !seen {
+		s.toolOrder = append(s.toolOrder, part.ID)
+	}
+	s.toolCalls[part.ID] = call
+	s.mu.Unlock()
+	s.notify()
+}
+
+// notify wakes whoever watches changed (the budget); a pending signal already covers this one
+func (s *runStats) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// spent returns the cost, output tokens (including reasoning) and tool calls so far
+func (s *runStats) spent() (cost, output float64, toolCalls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range s.messages {
+		cost += msg.Cost
+		output += msg.Tokens.Output + msg.Tokens.Reasoning
+	}
+	return cost, output, len(s.toolCalls)
+}
+
+// apply fills the usage, cost, model and tool calls of result
+func (s *runStats) apply(result *AgentResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last opencode.AssistantMessage
+	for _, msg := range s.messages {
+		result.Cost += msg.Cost
+		result.Usage.Input += msg.Tokens.Input
+		result.Usage.Output += msg.Tokens.Output
+		result.Usage.Reasoning += msg.Tokens.Reasoning
+		result.Usage.CacheRead += msg.Tokens.Cache.Read
+		result.Usage.CacheWrite += msg.Tokens.Cache.Write
+		if msg.Time.Created >= last.Time.Created {
+			last = msg
+		}
+	}
+	if last.ModelID != "" {
+		result.Model = last.ProviderID + "/" + last.ModelID
+	}
+
+	result.ToolCalls = make([]ToolCall, 0, len(s.toolOrder))
+	for _, id := range s.toolOrder {
+		result.ToolCalls = append(result.ToolCalls, s.toolCalls[id])
+	}
+}
@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestUsageError(t *testing.T) {
+	stats := newRunStats()
+	stats.observeMessage(opencode.AssistantMessage{
+		ID:         "msg_a",
+		ProviderID: "anthropic",
+		ModelID:    "claude-haiku-4-5",
+		Cost:       0.25,
+		Tokens:     opencode.AssistantMessageTokens{Input: 1000, Output: 200},
+	})
+	params := opencode.SessionPromptParams{Agent: opencode.F("big-brain")}
+	exceeded := &BudgetExceededError{Limit: "cost", Used: "$0.25", Max: "$0.20"}
+
+	err := usageError(exceeded, stats, "ses_1", params, time.Now())
+	var usage *UsageError
+	if !errors.As(err, &usage) {
+		t.Fatalf("usageError() = %T, want *UsageError", err)
+	}
+	if !errors.As(err, new(*BudgetExceededError)) {
+		t.Errorf("usageError() lost the budget error: %v", err)
+	}
+	result := usage.Result
+	if result.SessionID != "ses_1" || result.Agent != "big-brain" || result.Model != "anthropic/claude-haiku-4-5" ||
+		result.Cost != 0.25 || result.Usage.Output != 200 || result.Error != exceeded.Error() {
+		t.Errorf("usage result = %+v", result)
+	}
+
+	// Interrupted while backing off: still an *InterruptedError, usage kept
+	stopped := interrupted(err, syscall.SIGINT, "ses_1")
+	if !errors.As(stopped, new(*InterruptedError)) || !errors.As(stopped, &usage) || usage.Result.Cost != 0.25 {
+		t.Errorf("interrupted() = %#v, want an *InterruptedError with the usage", stopped)
+	}
+
+	// A partial result carries its own usage
+	partial := &PartialError{Result: &AgentResult{Output: "half"}, Err: exceeded}
+	if got := usageError(partial, stats, "ses_1", params, time.Now()); got != error(partial) {
+		t.Errorf("usageError(partial) = %v, want it unchanged", got)
+	}
+}